@@ -3,9 +3,13 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"time"
 
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/mockserver"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/recording"
 	"github.com/mslomnicki/LMURacingTelemetry/pkg/telemetry"
 	"github.com/mslomnicki/LMURacingTelemetry/pkg/ui"
 )
@@ -17,12 +21,39 @@ func main() {
 	}
 	log.SetOutput(logFile)
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "replay":
+			runReplay(os.Args[2:], logFile)
+			return
+		case "mock-server":
+			runMockServer(os.Args[2:])
+			return
+		}
+	}
+
 	host := flag.String("host", "localhost", "WebSocket server hostname or IP address")
 	wsPort := flag.String("ws-port", "6398", "WebSocket server port")
 	restPort := flag.String("rest-port", "6397", "REST API server port")
+	record := flag.String("record", "", "Record raw WebSocket frames to the given session file")
+	trackConfig := flag.String("track-config", "track_config.json", "Speed trap and mini-sector definitions per track")
+	headless := flag.Bool("headless", false, "Run without the terminal UI, logging to stdout as well as the log file")
+	apiAddr := flag.String("api", "", "Serve the live state as JSON on this address, e.g. :8080")
 	flag.Parse()
 
 	monitor := telemetry.NewMonitor(*host, *wsPort, *restPort)
+	if *headless {
+		enableHeadless(monitor, logFile)
+	}
+	enableAPI(monitor, *apiAddr)
+	if err := monitor.LoadTrackConfig(*trackConfig); err != nil {
+		log.Fatalf("Failed to load track config: %v", err)
+	}
+	if *record != "" {
+		if err := monitor.EnableRecording(*record); err != nil {
+			log.Fatalf("Failed to start recording: %v", err)
+		}
+	}
 
 	fmt.Printf("Starting LMU Racing Telemetry Monitor %s...\n", ui.Version)
 	fmt.Printf("Connecting to ws://%s:%s and REST http://%s:%s\n", *host, *wsPort, *host, *restPort)
@@ -32,3 +63,93 @@ func main() {
 		log.Fatalf("Error running telemetry monitor: %v", err)
 	}
 }
+
+func enableHeadless(monitor *telemetry.Monitor, logFile *os.File) {
+	log.SetOutput(io.MultiWriter(logFile, os.Stdout))
+	monitor.EnableHeadless()
+}
+
+func enableAPI(monitor *telemetry.Monitor, addr string) {
+	if addr == "" {
+		return
+	}
+	if err := monitor.EnableAPI(addr); err != nil {
+		log.Fatalf("Failed to start API server: %v", err)
+	}
+	fmt.Printf("Serving the JSON API on %s\n", addr)
+}
+
+func runReplay(args []string, logFile *os.File) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	speed := flags.Float64("speed", 1, "Playback speed multiplier, 0 plays as fast as possible")
+	start := flags.Duration("start", 0, "Skip this far into the recording before playing")
+	trackConfig := flags.String("track-config", "track_config.json", "Speed trap and mini-sector definitions per track")
+	headless := flags.Bool("headless", false, "Run without the terminal UI and exit when the recording ends")
+	apiAddr := flags.String("api", "", "Serve the replayed state as JSON on this address, e.g. :8080")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s replay [options] <session file>\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		os.Exit(2)
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *speed < 0 {
+		log.Fatalf("Invalid replay speed: %g", *speed)
+	}
+
+	player, err := recording.NewPlayer(flags.Arg(0), *speed)
+	if err != nil {
+		log.Fatalf("Failed to open recording: %v", err)
+	}
+	if *start > 0 {
+		player.Seek(*start)
+	}
+
+	fmt.Printf("Replaying %s with LMU Racing Telemetry Monitor %s...\n", flags.Arg(0), ui.Version)
+
+	monitor := telemetry.NewMonitorWithSource(player)
+	if *headless {
+		enableHeadless(monitor, logFile)
+	}
+	enableAPI(monitor, *apiAddr)
+	if err := monitor.LoadTrackConfig(*trackConfig); err != nil {
+		log.Fatalf("Failed to load track config: %v", err)
+	}
+	if err := monitor.Run(); err != nil {
+		log.Fatalf("Error running replay: %v", err)
+	}
+}
+
+func runMockServer(args []string) {
+	flags := flag.NewFlagSet("mock-server", flag.ExitOnError)
+	host := flags.String("host", "localhost", "Address to listen on")
+	wsPort := flags.String("ws-port", "6398", "WebSocket server port")
+	restPort := flags.String("rest-port", "6397", "REST API server port")
+	cars := flags.Int("cars", 24, "Number of simulated cars")
+	seed := flags.Int64("seed", time.Now().UnixNano(), "Random seed for the simulation")
+	scale := flags.Float64("time-scale", 1, "Simulated seconds per real second")
+	if err := flags.Parse(args); err != nil {
+		os.Exit(2)
+	}
+	if *cars < 1 {
+		log.Fatalf("Invalid number of cars: %d", *cars)
+	}
+	if *scale <= 0 {
+		log.Fatalf("Invalid time scale: %g", *scale)
+	}
+
+	server := mockserver.NewServer(*cars, *seed)
+	server.SetTimeScale(*scale)
+
+	fmt.Printf("Starting LMU mock server %s with %d cars...\n", ui.Version, *cars)
+	fmt.Printf("Serving ws://%s:%s/websocket/controlpanel and REST http://%s:%s\n", *host, *wsPort, *host, *restPort)
+	fmt.Printf("Press Ctrl+C to exit\n\n")
+
+	if err := server.ListenAndServe(*host, *wsPort, *restPort); err != nil {
+		log.Fatalf("Error running mock server: %v", err)
+	}
+}
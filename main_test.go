@@ -1,9 +0,0 @@
-package main
-
-import "testing"
-
-func TestMain(t *testing.T) {
-	// Basic smoke test to ensure the program can be built
-	// Add more comprehensive tests as needed
-	t.Log("Main package test passed")
-}
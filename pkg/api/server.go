@@ -0,0 +1,221 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+// Server serves the latest monitor snapshot as JSON, both on request and
+// pushed to WebSocket clients.
+type Server struct {
+	mu       sync.RWMutex
+	snapshot *models.Snapshot
+	http     *http.Server
+	listener net.Listener
+	upgrader websocket.Upgrader
+	streamMu sync.Mutex
+	clients  map[*client]struct{}
+	pushed   *models.Snapshot
+	failing  map[string]bool
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// Standing is a car in the standings: the live data from the game with the
+// stats the monitor keeps for it. Lap histories are left out and served per
+// driver.
+type Standing struct {
+	*models.StandingsData
+	Stats *models.DriverStats `json:"stats"`
+}
+
+func NewServer() *Server {
+	return &Server{
+		// Overlays and dashboards connect from pages served elsewhere.
+		upgrader: websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }},
+		clients:  make(map[*client]struct{}),
+		failing:  make(map[string]bool),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Update replaces the snapshot being served. Snapshots must not be modified
+// once handed over.
+func (s *Server) Update(snapshot *models.Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot = snapshot
+}
+
+func (s *Server) current() *models.Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.snapshot
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/session", s.handleSession)
+	mux.HandleFunc("GET /api/standings", s.handleStandings)
+	mux.HandleFunc("GET /api/drivers/{id}/laps", s.handleLaps)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	mux.HandleFunc("GET /api/incidents", s.handleIncidents)
+	mux.HandleFunc("GET /api/ws", s.handleStream)
+	return mux
+}
+
+// Listen starts serving and pushing updates on addr in the background.
+func (s *Server) Listen(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.http = &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := s.http.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("API server error: %v", err)
+		}
+	}()
+	go s.Run()
+	return nil
+}
+
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
+
+func (s *Server) Close() error {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+	})
+	// Shutdown does not close hijacked WebSocket connections.
+	s.closeClients()
+	if s.http == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.http.Shutdown(ctx)
+}
+
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	snapshot := s.current()
+	if snapshot == nil || snapshot.Session == nil {
+		writeError(w, http.StatusServiceUnavailable, "no session yet")
+		return
+	}
+	writeJSON(w, snapshot.Session)
+}
+
+func (s *Server) handleStandings(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, standings(s.current()))
+}
+
+func standings(snapshot *models.Snapshot) []Standing {
+	standings := []Standing{}
+	if snapshot != nil {
+		for key, driver := range snapshot.Drivers {
+			var stats *models.DriverStats
+			if snapshotStats, ok := snapshot.Stats[key]; ok {
+				statsCopy := *snapshotStats
+				statsCopy.Laps = nil
+				stats = &statsCopy
+			}
+			standings = append(standings, Standing{StandingsData: driver, Stats: stats})
+		}
+	}
+
+	sort.Slice(standings, func(i int, j int) bool {
+		a, b := standings[i], standings[j]
+		if (a.Position > 0) != (b.Position > 0) {
+			return a.Position > 0
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.SlotID < b.SlotID
+	})
+	return standings
+}
+
+func (s *Server) handleLaps(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid driver id")
+		return
+	}
+
+	snapshot := s.current()
+	if snapshot == nil {
+		writeError(w, http.StatusNotFound, "unknown driver")
+		return
+	}
+	stats, ok := snapshot.Stats[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown driver")
+		return
+	}
+	laps := stats.Laps
+	if laps == nil {
+		laps = []models.LapRecord{}
+	}
+	writeJSON(w, laps)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	events := []models.RaceEvent{}
+	if snapshot := s.current(); snapshot != nil && snapshot.Events != nil {
+		events = snapshot.Events
+	}
+	writeJSON(w, events)
+}
+
+func (s *Server) handleIncidents(w http.ResponseWriter, r *http.Request) {
+	incidents := []models.Incident{}
+	if snapshot := s.current(); snapshot != nil && snapshot.Incidents != nil {
+		incidents = snapshot.Incidents
+	}
+	writeJSON(w, incidents)
+}
+
+// writeJSON encodes the whole body before writing anything, so a value that
+// cannot be encoded, such as NaN, is answered with an error and not with a
+// truncated 200.
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("Error encoding API response: %v", err)
+		writeError(w, http.StatusInternalServerError, "could not encode response")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	// Dashboards are usually served from somewhere else.
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if _, err := w.Write(append(encoded, '\n')); err != nil {
+		log.Printf("Error writing API response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": message}); err != nil {
+		log.Printf("Error writing API response: %v", err)
+	}
+}
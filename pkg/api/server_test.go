@@ -0,0 +1,210 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+func get(t *testing.T, handler http.Handler, path string, body interface{}) int {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	if body != nil && recorder.Code == http.StatusOK {
+		if err := json.Unmarshal(recorder.Body.Bytes(), body); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+	}
+	return recorder.Code
+}
+
+func TestEndpoints(t *testing.T) {
+	server := NewServer()
+	handler := server.Handler()
+
+	if code := get(t, handler, "/api/session", nil); code != http.StatusServiceUnavailable {
+		t.Errorf("session before any data: status %d", code)
+	}
+
+	server.Update(&models.Snapshot{
+		Session: &models.SessionData{TrackName: "Spa", Session: "RACE1"},
+		Drivers: map[int]*models.StandingsData{
+			3: {SlotID: 3, DriverName: "Driver Three", Position: 2},
+			7: {SlotID: 7, DriverName: "Driver Seven", Position: 1},
+		},
+		Stats: map[int]*models.DriverStats{
+			3: {SlotID: 3, Laps: []models.LapRecord{{Lap: 1, LapTime: 140.5}, {Lap: 2, LapTime: 139.9}}},
+			7: {SlotID: 7, Laps: []models.LapRecord{{Lap: 1, LapTime: 138.2}}},
+		},
+		Events: []models.RaceEvent{{Kind: models.EventPhase, SlotID: -1, Message: "Green flag"}},
+	})
+
+	var session models.SessionData
+	if code := get(t, handler, "/api/session", &session); code != http.StatusOK || session.TrackName != "Spa" {
+		t.Errorf("session: status %d, %+v", code, session)
+	}
+
+	var standings []map[string]json.RawMessage
+	if code := get(t, handler, "/api/standings", &standings); code != http.StatusOK || len(standings) != 2 {
+		t.Fatalf("standings: status %d, %d entries", code, len(standings))
+	}
+	var leader string
+	json.Unmarshal(standings[0]["driverName"], &leader)
+	if leader != "Driver Seven" {
+		t.Errorf("standings start with %q, want the leader", leader)
+	}
+	var stats map[string]json.RawMessage
+	json.Unmarshal(standings[0]["stats"], &stats)
+	if _, ok := stats["laps"]; ok {
+		t.Error("standings include lap histories")
+	}
+
+	var laps []models.LapRecord
+	if code := get(t, handler, "/api/drivers/3/laps", &laps); code != http.StatusOK || len(laps) != 2 || laps[1].LapTime != 139.9 {
+		t.Errorf("laps: status %d, %+v", code, laps)
+	}
+	if code := get(t, handler, "/api/drivers/9/laps", nil); code != http.StatusNotFound {
+		t.Errorf("laps of unknown driver: status %d", code)
+	}
+	if code := get(t, handler, "/api/drivers/abc/laps", nil); code != http.StatusBadRequest {
+		t.Errorf("laps of invalid driver: status %d", code)
+	}
+
+	var events []models.RaceEvent
+	if code := get(t, handler, "/api/events", &events); code != http.StatusOK || len(events) != 1 || events[0].Message != "Green flag" {
+		t.Errorf("events: status %d, %+v", code, events)
+	}
+}
+
+func TestUnencodableValues(t *testing.T) {
+	server := NewServer()
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	go server.Run()
+	defer server.Close()
+
+	server.Update(&models.Snapshot{Session: &models.SessionData{TrackName: "Spa", TrackTemp: math.NaN()}})
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/session", nil))
+	if recorder.Code != http.StatusInternalServerError || !strings.Contains(recorder.Body.String(), "error") {
+		t.Errorf("session with NaN: status %d, body %q", recorder.Code, recorder.Body.String())
+	}
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/api/ws?topics=session"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	var problem string
+	next(t, conn, "error", &problem)
+	if problem != "could not encode session" {
+		t.Errorf("unexpected error %q", problem)
+	}
+
+	server.Update(&models.Snapshot{Session: &models.SessionData{TrackName: "Spa", TrackTemp: 31}})
+	var session models.SessionData
+	next(t, conn, TopicSession, &session)
+	if session.TrackTemp != 31 {
+		t.Errorf("unexpected session %+v", session)
+	}
+}
+
+// next reads messages until one of the given type arrives.
+func next(t *testing.T, conn *websocket.Conn, msgType string, body interface{}) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var message models.WSMessage
+		if err := conn.ReadJSON(&message); err != nil {
+			t.Fatalf("waiting for %s: %v", msgType, err)
+		}
+		if message.Type != msgType {
+			continue
+		}
+		if err := json.Unmarshal(message.Body, body); err != nil {
+			t.Fatalf("decode %s: %v", msgType, err)
+		}
+		return
+	}
+}
+
+func TestStreamTopics(t *testing.T) {
+	server := NewServer()
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	go server.Run()
+	defer server.Close()
+
+	green := models.RaceEvent{Kind: models.EventPhase, SlotID: -1, Message: "Green flag"}
+	server.Update(&models.Snapshot{
+		Session: &models.SessionData{TrackName: "Spa", Session: "RACE1"},
+		Drivers: map[int]*models.StandingsData{7: {SlotID: 7, DriverName: "Driver Seven", Position: 1, VehicleNumber: "7"}},
+		Stats:   map[int]*models.DriverStats{7: {SlotID: 7, BestLapTime: 138.2}},
+		Events:  []models.RaceEvent{green},
+	})
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/api/ws?topics=standings,events"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	var standings []Standing
+	next(t, conn, TopicStandings, &standings)
+	if len(standings) != 1 || standings[0].VehicleNumber != "7" || standings[0].Stats.BestLapTime != 138.2 {
+		t.Errorf("unexpected standings %+v", standings)
+	}
+	var events []models.RaceEvent
+	next(t, conn, TopicEvents, &events)
+	if len(events) != 1 || events[0].Message != "Green flag" {
+		t.Errorf("unexpected events %+v", events)
+	}
+
+	server.Update(&models.Snapshot{
+		Session: &models.SessionData{TrackName: "Spa", Session: "RACE1"},
+		Events:  []models.RaceEvent{green, {Kind: models.EventSectorYellow, SlotID: -1, Message: "Yellow flag in sector 2"}},
+	})
+	next(t, conn, TopicEvents, &events)
+	if len(events) != 1 || events[0].Message != "Yellow flag in sector 2" {
+		t.Errorf("expected only the new event, got %+v", events)
+	}
+
+	server.Update(&models.Snapshot{
+		Generation: 1,
+		Session:    &models.SessionData{TrackName: "Spa", Session: "RACE2"},
+		Events: []models.RaceEvent{
+			{Kind: models.EventPhase, SlotID: -1, Message: "Formation lap"},
+			{Kind: models.EventPhase, SlotID: -1, Message: "Green flag"},
+		},
+	})
+	var generation int
+	next(t, conn, "reset", &generation)
+	next(t, conn, TopicEvents, &events)
+	if generation != 1 || len(events) != 2 || events[0].Message != "Formation lap" {
+		t.Errorf("expected a reset and all events of the new session, got %d and %+v", generation, events)
+	}
+
+	if err := conn.WriteJSON(map[string][]string{"subscribe": {"session", "laps"}}); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	var session models.SessionData
+	next(t, conn, TopicSession, &session)
+	if session.TrackName != "Spa" {
+		t.Errorf("unexpected session %+v", session)
+	}
+	var problem string
+	next(t, conn, "error", &problem)
+	if !strings.Contains(problem, "laps") {
+		t.Errorf("unexpected error %q", problem)
+	}
+}
@@ -0,0 +1,305 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+const (
+	TopicSession   = "session"
+	TopicStandings = "standings"
+	TopicBests     = "bests"
+	TopicEvents    = "events"
+	TopicIncidents = "incidents"
+)
+
+// resetMessage is sent to every client when a new session starts.
+const resetMessage = "reset"
+
+var topics = []string{TopicSession, TopicStandings, TopicBests, TopicEvents, TopicIncidents}
+
+const (
+	pushInterval  = 250 * time.Millisecond
+	clientBuffer  = 64
+	writeTimeout  = 5 * time.Second
+	maxClientRead = 4096
+)
+
+type client struct {
+	conn   *websocket.Conn
+	send   chan []byte
+	topics map[string]bool
+}
+
+// subscription is what clients send to change their topics.
+type subscription struct {
+	Subscribe   []string `json:"subscribe"`
+	Unsubscribe []string `json:"unsubscribe"`
+}
+
+// Run pushes new snapshots to the WebSocket clients until Close is called.
+func (s *Server) Run() {
+	ticker := time.NewTicker(pushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+		}
+		s.push()
+	}
+}
+
+// push sends the state of the latest snapshot to every client subscribed to
+// it. Events and incidents are only ever appended to during a session, so only
+// the new ones are sent. When a new session starts clients are told to reset
+// and get the events and incidents of the new session from the start.
+func (s *Server) push() {
+	snapshot := s.current()
+
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+	if snapshot == nil || snapshot == s.pushed {
+		return
+	}
+	previous := s.pushed
+	s.pushed = snapshot
+	if len(s.clients) == 0 {
+		return
+	}
+
+	newSession := previous == nil || previous.Generation != snapshot.Generation
+	if newSession && previous != nil {
+		if message, err := encodeMessage(resetMessage, snapshot.Generation); err == nil {
+			for c := range s.clients {
+				s.sendTo(c, message)
+			}
+		}
+	}
+
+	if snapshot.Session != nil {
+		s.publish(TopicSession, snapshot.Session)
+	}
+	s.publish(TopicStandings, standings(snapshot))
+	s.publish(TopicBests, snapshot.IdealLaps)
+
+	var previousEvents []models.RaceEvent
+	var previousIncidents []models.Incident
+	if !newSession {
+		previousEvents, previousIncidents = previous.Events, previous.Incidents
+	}
+	if events := snapshot.Events[len(previousEvents):]; len(events) > 0 {
+		s.publish(TopicEvents, events)
+	}
+	if incidents := snapshot.Incidents[len(previousIncidents):]; len(incidents) > 0 {
+		s.publish(TopicIncidents, incidents)
+	}
+}
+
+// publish sends a message to all clients subscribed to topic. A topic that
+// cannot be encoded is logged and reported to its subscribers once, not on
+// every push. It must be called with streamMu held.
+func (s *Server) publish(topic string, body interface{}) {
+	var subscribers []*client
+	for c := range s.clients {
+		if c.topics[topic] {
+			subscribers = append(subscribers, c)
+		}
+	}
+	if len(subscribers) == 0 {
+		return
+	}
+
+	message, err := encodeMessage(topic, body)
+	if err != nil {
+		if !s.failing[topic] {
+			log.Printf("Error marshaling %s: %v", topic, err)
+			for _, c := range subscribers {
+				s.sendErrorLocked(c, "could not encode "+topic)
+			}
+		}
+		s.failing[topic] = true
+		return
+	}
+	delete(s.failing, topic)
+	for _, c := range subscribers {
+		s.sendTo(c, message)
+	}
+}
+
+// sendTo queues a message for a client and drops the client if it cannot
+// keep up. It must be called with streamMu held.
+func (s *Server) sendTo(c *client, message []byte) {
+	if _, ok := s.clients[c]; !ok {
+		return
+	}
+	select {
+	case c.send <- message:
+	default:
+		log.Printf("Dropping slow API client %s", c.conn.RemoteAddr())
+		s.removeClient(c)
+	}
+}
+
+// removeClient must be called with streamMu held.
+func (s *Server) removeClient(c *client) {
+	if _, ok := s.clients[c]; !ok {
+		return
+	}
+	delete(s.clients, c)
+	close(c.send)
+}
+
+func (s *Server) closeClients() {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+	for c := range s.clients {
+		s.removeClient(c)
+	}
+}
+
+func encodeMessage(topic string, body interface{}) ([]byte, error) {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(models.WSMessage{Type: topic, Body: raw})
+}
+
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("API WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	c := &client{conn: conn, send: make(chan []byte, clientBuffer), topics: make(map[string]bool)}
+	s.streamMu.Lock()
+	s.clients[c] = struct{}{}
+	s.streamMu.Unlock()
+	log.Printf("API client connected: %s", conn.RemoteAddr())
+
+	go s.writeClient(c)
+	if query := r.URL.Query().Get("topics"); query != "" {
+		s.subscribe(c, strings.Split(query, ","), nil)
+	}
+	s.readClient(c)
+}
+
+func (s *Server) writeClient(c *client) {
+	defer c.conn.Close()
+	for message := range c.send {
+		c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			log.Printf("Error writing to API client %s: %v", c.conn.RemoteAddr(), err)
+			s.streamMu.Lock()
+			s.removeClient(c)
+			s.streamMu.Unlock()
+			// Drain so queued sends do not block.
+			for range c.send {
+			}
+			return
+		}
+	}
+}
+
+func (s *Server) readClient(c *client) {
+	c.conn.SetReadLimit(maxClientRead)
+	for {
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		var request subscription
+		if err := json.Unmarshal(message, &request); err != nil {
+			s.sendError(c, "invalid subscription: "+err.Error())
+			continue
+		}
+		s.subscribe(c, request.Subscribe, request.Unsubscribe)
+	}
+
+	s.streamMu.Lock()
+	s.removeClient(c)
+	s.streamMu.Unlock()
+	log.Printf("API client disconnected: %s", c.conn.RemoteAddr())
+}
+
+// subscribe changes the topics of a client and sends it the current state of
+// every topic it newly subscribed to, including all events and incidents of
+// the session so far.
+func (s *Server) subscribe(c *client, subscribe []string, unsubscribe []string) {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+	if _, ok := s.clients[c]; !ok {
+		return
+	}
+
+	for _, topic := range unsubscribe {
+		delete(c.topics, strings.TrimSpace(topic))
+	}
+	for _, topic := range subscribe {
+		topic = strings.TrimSpace(topic)
+		if !slices.Contains(topics, topic) {
+			s.sendErrorLocked(c, fmt.Sprintf("unknown topic %q", topic))
+			continue
+		}
+		if c.topics[topic] {
+			continue
+		}
+		c.topics[topic] = true
+		if s.pushed != nil {
+			s.sendCurrent(c, topic, s.pushed)
+		}
+	}
+}
+
+// sendCurrent must be called with streamMu held.
+func (s *Server) sendCurrent(c *client, topic string, snapshot *models.Snapshot) {
+	var body interface{}
+	switch topic {
+	case TopicSession:
+		if snapshot.Session == nil {
+			return
+		}
+		body = snapshot.Session
+	case TopicStandings:
+		body = standings(snapshot)
+	case TopicBests:
+		body = snapshot.IdealLaps
+	case TopicEvents:
+		body = append([]models.RaceEvent{}, snapshot.Events...)
+	case TopicIncidents:
+		body = append([]models.Incident{}, snapshot.Incidents...)
+	}
+	message, err := encodeMessage(topic, body)
+	if err != nil {
+		log.Printf("Error marshaling %s: %v", topic, err)
+		s.sendErrorLocked(c, "could not encode "+topic)
+		return
+	}
+	s.sendTo(c, message)
+}
+
+func (s *Server) sendError(c *client, message string) {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+	s.sendErrorLocked(c, message)
+}
+
+func (s *Server) sendErrorLocked(c *client, message string) {
+	encoded, err := encodeMessage("error", message)
+	if err != nil {
+		return
+	}
+	s.sendTo(c, encoded)
+}
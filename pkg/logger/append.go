@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+)
+
+type appendWriter struct {
+	file   *os.File
+	writer *csv.Writer
+}
+
+func newAppendWriter(filename string, header []string) (*appendWriter, error) {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+
+	w := &appendWriter{file: file, writer: csv.NewWriter(file)}
+	w.writer.Comma = ';'
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat CSV file: %w", err)
+	}
+	if info.Size() == 0 {
+		if err := w.write(header); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to write CSV header: %w", err)
+		}
+	}
+	return w, nil
+}
+
+func (w *appendWriter) write(record []string) error {
+	if err := w.writer.Write(record); err != nil {
+		return err
+	}
+	// Flush every row so a spreadsheet opened mid-session never sees half a line.
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *appendWriter) close() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
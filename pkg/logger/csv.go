@@ -3,18 +3,33 @@ package logger
 import (
 	"encoding/csv"
 	"fmt"
+	"log"
 	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
 )
 
+const (
+	flushInterval    = 5 * time.Second
+	minWriteInterval = time.Second
+)
+
 type CSVLogger struct {
+	prefix      string
 	filename    string
-	driverStats map[string]*models.DriverStats
+	mu          sync.Mutex
+	driverStats map[int]models.DriverStats
+	departed    []models.DriverStats
 	session     *models.SessionData
+	dirty       bool
+	lastWrite   time.Time
+	changed     chan struct{}
+	stopChan    chan struct{}
+	done        chan struct{}
 }
 
 func NewCSVLogger(session *models.SessionData) (*CSVLogger, error) {
@@ -25,62 +40,218 @@ func NewCSVLogger(session *models.SessionData) (*CSVLogger, error) {
 	now := time.Now()
 	trackName := strings.ReplaceAll(session.TrackName, " ", "_")
 	sessionName := strings.ReplaceAll(session.Session, " ", "_")
-	filename := fmt.Sprintf("%s_%s_%s_telemetry.csv",
+	prefix := fmt.Sprintf("%s_%s_%s",
 		now.Format("2006-01-02_15-04-05"),
 		trackName,
 		sessionName)
+	return newCSVLogger(session, prefix), nil
+}
 
-	return &CSVLogger{
-		filename:    filename,
-		driverStats: make(map[string]*models.DriverStats),
+// Restart removes the files written under the prefix of a closed logger and
+// starts a new logger that writes them again from scratch. A replay that is
+// rewound uses it so rows from before the rewind are not written twice.
+func (l *CSVLogger) Restart() (*CSVLogger, error) {
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CSV files: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, l.prefix+"_") && strings.HasSuffix(name, ".csv") {
+			if err := os.Remove(name); err != nil {
+				return nil, fmt.Errorf("failed to remove CSV file: %w", err)
+			}
+		}
+	}
+	return newCSVLogger(l.session, l.prefix), nil
+}
+
+// Logs reports whether the logger writes the files of the given session.
+func (l *CSVLogger) Logs(session *models.SessionData) bool {
+	return session != nil && session.TrackName == l.session.TrackName && session.Session == l.session.Session
+}
+
+func newCSVLogger(session *models.SessionData, prefix string) *CSVLogger {
+	l := &CSVLogger{
+		prefix:      prefix,
+		filename:    prefix + "_telemetry.csv",
+		driverStats: make(map[int]models.DriverStats),
 		session:     session,
-	}, nil
+		changed:     make(chan struct{}, 1),
+		stopChan:    make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+func (l *CSVLogger) SessionFilename(suffix string) string {
+	return fmt.Sprintf("%s_%s.csv", l.prefix, suffix)
 }
 
 func (l *CSVLogger) UpdateDriver(stats *models.DriverStats) {
-	key := stats.DriverName
-	l.driverStats[key] = stats
+	key := stats.SlotID
+
+	l.mu.Lock()
+	previous, exists := l.driverStats[key]
+	l.driverStats[key] = *stats
+	if exists && !csvFieldsChanged(&previous, stats) {
+		l.mu.Unlock()
+		return
+	}
+	l.dirty = true
+	l.mu.Unlock()
+
+	if !exists || previous.LapsCompleted != stats.LapsCompleted || previous.Position != stats.Position {
+		select {
+		case l.changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// RemoveDriver keeps the last stats of a car that left the session in the
+// summary, so its slot can be taken by another car.
+func (l *CSVLogger) RemoveDriver(slotID int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if stats, ok := l.driverStats[slotID]; ok {
+		l.departed = append(l.departed, stats)
+		delete(l.driverStats, slotID)
+		l.dirty = true
+	}
+}
+
+func csvFieldsChanged(a *models.DriverStats, b *models.DriverStats) bool {
+	return a.Position != b.Position ||
+		len(a.Drivers) != len(b.Drivers) ||
+		a.SteamID != b.SteamID ||
+		a.DriverName != b.DriverName ||
+		a.CarClass != b.CarClass ||
+		a.VehicleNumber != b.VehicleNumber ||
+		a.VehicleName != b.VehicleName ||
+		a.LapsCompleted != b.LapsCompleted ||
+		a.MaxSpeed != b.MaxSpeed ||
+		a.BestLapTime != b.BestLapTime ||
+		a.BestSector1 != b.BestSector1 ||
+		a.BestSector2 != b.BestSector2 ||
+		a.BestSector3 != b.BestSector3 ||
+		a.MaxSpeedOnBestLap != b.MaxSpeedOnBestLap ||
+		a.BestLapTimeCalculated != b.BestLapTimeCalculated ||
+		a.MaxSpeedOnBestLapCalc != b.MaxSpeedOnBestLapCalc ||
+		a.TheoreticalBestLap != b.TheoreticalBestLap ||
+		a.StartPosition != b.StartPosition ||
+		a.Overtakes != b.Overtakes ||
+		a.TimesOvertaken != b.TimesOvertaken
+}
+
+func (l *CSVLogger) run() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stopChan:
+			return
+		case <-ticker.C:
+		case <-l.changed:
+			l.mu.Lock()
+			tooSoon := time.Since(l.lastWrite) < minWriteInterval
+			l.mu.Unlock()
+			if tooSoon {
+				// The ticker picks the change up with the next batch.
+				continue
+			}
+		}
+
+		if err := l.flush(); err != nil {
+			log.Printf("Error writing CSV file: %v", err)
+		}
+	}
+}
+
+func (l *CSVLogger) flush() error {
+	l.mu.Lock()
+	if !l.dirty {
+		l.mu.Unlock()
+		return nil
+	}
+	l.dirty = false
+	l.lastWrite = time.Now()
+	l.mu.Unlock()
+
 	if err := l.WriteCurrentState(); err != nil {
-		fmt.Printf("Error writing CSV file: %v\n", err)
+		l.mu.Lock()
+		l.dirty = true
+		l.mu.Unlock()
+		return err
 	}
+	return nil
 }
 
 func (l *CSVLogger) WriteCurrentState() error {
-	file, err := os.Create(l.filename)
+	l.mu.Lock()
+	drivers := make([]models.DriverStats, 0, len(l.driverStats)+len(l.departed))
+	drivers = append(drivers, l.departed...)
+	for _, stats := range l.driverStats {
+		drivers = append(drivers, stats)
+	}
+	l.mu.Unlock()
+
+	sort.Slice(drivers, func(i, j int) bool {
+		return drivers[i].Position < drivers[j].Position
+	})
+
+	// Write next to the target and rename over it, so a reader never sees a
+	// partially written file.
+	tmpFilename := l.filename + ".tmp"
+	file, err := os.Create(tmpFilename)
 	if err != nil {
 		return fmt.Errorf("failed to create CSV file: %w", err)
 	}
-	defer file.Close()
 
+	if err := writeSummary(file, drivers); err != nil {
+		file.Close()
+		os.Remove(tmpFilename)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpFilename)
+		return fmt.Errorf("failed to close CSV file: %w", err)
+	}
+	if err := os.Rename(tmpFilename, l.filename); err != nil {
+		os.Remove(tmpFilename)
+		return fmt.Errorf("failed to replace CSV file: %w", err)
+	}
+	return nil
+}
+
+func writeSummary(file *os.File, drivers []models.DriverStats) error {
 	writer := csv.NewWriter(file)
 	writer.Comma = ';'
-	defer writer.Flush()
 
 	header := []string{
-		"Position",
-		"SteamID", "DriverName", "CarClass", "CarNumber", "VehicleName",
+		"Position", "SlotID",
+		"SteamID", "DriverName", "Drivers", "CarClass", "CarNumber", "VehicleName",
 		"LapsCompleted", "MaxSpeed", "BestLapTime",
 		"BestSector1", "BestSector2", "BestSector3",
 		"MaxSpeedOnBestLap", "BestLapTimeCalculated", "BestSector1Calculated", "BestSector2Calculated", "BestSector3Calculated", "MaxSpeedOnBestLapCalc",
+		"IdealSector1", "IdealSector2", "IdealSector3", "TheoreticalBestLap",
+		"StartPosition", "PositionsGained", "Overtakes", "TimesOvertaken",
 	}
 	if err := writer.Write(header); err != nil {
 		return fmt.Errorf("failed to write CSV header: %w", err)
 	}
 
-	var drivers []*models.DriverStats
-	for _, stats := range l.driverStats {
-		drivers = append(drivers, stats)
-	}
-
-	sort.Slice(drivers, func(i, j int) bool {
-		return drivers[i].Position < drivers[j].Position
-	})
-
 	for _, stats := range drivers {
 		record := []string{
 			fmt.Sprintf("%d", stats.Position),
+			fmt.Sprintf("%d", stats.SlotID),
 			fmt.Sprintf("%d", stats.SteamID),
 			stats.DriverName,
+			strings.Join(stats.Drivers, ", "),
 			stats.CarClass,
 			fmt.Sprintf("'%s'", stats.VehicleNumber),
 			stats.VehicleName,
@@ -96,6 +267,14 @@ func (l *CSVLogger) WriteCurrentState() error {
 			formatTime(stats.BestSector2Calculated),
 			formatTime(stats.BestSector3Calculated),
 			fmt.Sprintf("%.1f", stats.MaxSpeedOnBestLapCalc),
+			formatTime(stats.IdealSector1),
+			formatTime(stats.IdealSector2),
+			formatTime(stats.IdealSector3),
+			formatTime(stats.TheoreticalBestLap),
+			fmt.Sprintf("%d", stats.StartPosition),
+			fmt.Sprintf("%d", stats.PositionsGained()),
+			fmt.Sprintf("%d", stats.Overtakes),
+			fmt.Sprintf("%d", stats.TimesOvertaken),
 		}
 
 		if err := writer.Write(record); err != nil {
@@ -103,10 +282,16 @@ func (l *CSVLogger) WriteCurrentState() error {
 		}
 	}
 
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV file: %w", err)
+	}
 	return nil
 }
 
 func (l *CSVLogger) Close() error {
+	close(l.stopChan)
+	<-l.done
 	return l.WriteCurrentState()
 }
 
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+func TestCSVLoggerWritesSummaryOnClose(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	l, err := NewCSVLogger(&models.SessionData{TrackName: "Spa Francorchamps", Session: "QUALIFY1"})
+	if err != nil {
+		t.Fatalf("NewCSVLogger: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		l.UpdateDriver(&models.DriverStats{SlotID: 4, DriverName: "Second", Position: 2, LapsCompleted: i})
+		l.UpdateDriver(&models.DriverStats{SlotID: 9, DriverName: "First", Position: 1, LapsCompleted: i, BestLapTime: 125.25})
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	content, err := os.ReadFile(l.filename)
+	if err != nil {
+		t.Fatalf("read CSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want header and 2 drivers:\n%s", len(lines), content)
+	}
+	if !strings.HasPrefix(lines[1], "1;9;0;First;") || !strings.Contains(lines[1], ";99;") || !strings.Contains(lines[1], "2:05.250") {
+		t.Errorf("unexpected first row %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "2;4;0;Second;") {
+		t.Errorf("unexpected second row %q", lines[2])
+	}
+
+	leftovers, _ := filepath.Glob("*.tmp")
+	if len(leftovers) != 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+}
@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"fmt"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+type EventLogger struct {
+	out *appendWriter
+}
+
+func NewEventLogger(filename string) (*EventLogger, error) {
+	header := []string{
+		"Time", "Kind", "SlotID", "CarClass", "CarNumber", "DriverName", "Lap", "Message",
+	}
+	out, err := newAppendWriter(filename, header)
+	if err != nil {
+		return nil, err
+	}
+	return &EventLogger{out: out}, nil
+}
+
+func (l *EventLogger) LogEvent(event models.RaceEvent) error {
+	slotID, carNumber, lap := "", "", ""
+	if event.SlotID >= 0 {
+		slotID = fmt.Sprintf("%d", event.SlotID)
+		carNumber = fmt.Sprintf("'%s'", event.VehicleNumber)
+		lap = fmt.Sprintf("%d", event.Lap)
+	}
+	record := []string{
+		event.Time.Format("15:04:05.000"),
+		event.Kind,
+		slotID,
+		event.CarClass,
+		carNumber,
+		event.DriverName,
+		lap,
+		event.Message,
+	}
+	if err := l.out.write(record); err != nil {
+		return fmt.Errorf("failed to write event record: %w", err)
+	}
+	return nil
+}
+
+func (l *EventLogger) Close() error {
+	return l.out.close()
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"fmt"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+type IncidentLogger struct {
+	out *appendWriter
+}
+
+func NewIncidentLogger(filename string) (*IncidentLogger, error) {
+	header := []string{
+		"Time", "SlotID", "CarClass", "CarNumber", "DriverName",
+		"Kind", "Lap", "LapDistance", "Speed", "UnderYellow", "Detail",
+	}
+	out, err := newAppendWriter(filename, header)
+	if err != nil {
+		return nil, err
+	}
+	return &IncidentLogger{out: out}, nil
+}
+
+func (l *IncidentLogger) LogIncident(incident models.Incident) error {
+	record := []string{
+		incident.Time.Format("15:04:05.000"),
+		fmt.Sprintf("%d", incident.SlotID),
+		incident.CarClass,
+		fmt.Sprintf("'%s'", incident.VehicleNumber),
+		incident.DriverName,
+		incident.Kind,
+		fmt.Sprintf("%d", incident.Lap),
+		fmt.Sprintf("%.1f", incident.LapDistance),
+		fmt.Sprintf("%.1f", incident.Speed),
+		fmt.Sprintf("%t", incident.UnderYellow),
+		incident.Detail,
+	}
+	if err := l.out.write(record); err != nil {
+		return fmt.Errorf("failed to write incident record: %w", err)
+	}
+	return nil
+}
+
+func (l *IncidentLogger) Close() error {
+	return l.out.close()
+}
@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"fmt"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+type LapLogger struct {
+	out *appendWriter
+}
+
+func NewLapLogger(filename string) (*LapLogger, error) {
+	header := []string{
+		"SlotID", "DriverName", "SteamID", "CarClass", "CarNumber",
+		"Lap", "Sector1", "Sector2", "Sector3", "LapTime",
+		"MaxSpeed", "Position", "Pitted", "FuelFraction", "FuelUsed", "Flag", "Valid",
+	}
+	out, err := newAppendWriter(filename, header)
+	if err != nil {
+		return nil, err
+	}
+	return &LapLogger{out: out}, nil
+}
+
+func (l *LapLogger) LogLap(stats *models.DriverStats, lap models.LapRecord) error {
+	record := []string{
+		fmt.Sprintf("%d", stats.SlotID),
+		lap.DriverName,
+		fmt.Sprintf("%d", lap.SteamID),
+		stats.CarClass,
+		fmt.Sprintf("'%s'", stats.VehicleNumber),
+		fmt.Sprintf("%d", lap.Lap),
+		formatTime(lap.Sector1),
+		formatTime(lap.Sector2),
+		formatTime(lap.Sector3),
+		formatTime(lap.LapTime),
+		fmt.Sprintf("%.1f", lap.MaxSpeed),
+		fmt.Sprintf("%d", lap.Position),
+		fmt.Sprintf("%t", lap.Pitted),
+		fmt.Sprintf("%.3f", lap.FuelFraction),
+		fmt.Sprintf("%.3f", lap.FuelUsed),
+		lap.Flag,
+		fmt.Sprintf("%t", lap.Valid),
+	}
+	if err := l.out.write(record); err != nil {
+		return fmt.Errorf("failed to write lap record: %w", err)
+	}
+	return nil
+}
+
+func (l *LapLogger) Close() error {
+	return l.out.close()
+}
@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"fmt"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+type PitStopLogger struct {
+	out *appendWriter
+}
+
+func NewPitStopLogger(filename string) (*PitStopLogger, error) {
+	header := []string{
+		"SlotID", "CarClass", "CarNumber", "DriverName",
+		"Stop", "Lap", "EntryLapDistance", "EntryTime", "ExitTime",
+		"PitLaneTime", "StationaryTime", "FuelBefore", "FuelAfter", "Serviced", "InGarage", "Incomplete",
+	}
+	out, err := newAppendWriter(filename, header)
+	if err != nil {
+		return nil, err
+	}
+	return &PitStopLogger{out: out}, nil
+}
+
+func (l *PitStopLogger) LogPitStop(stats *models.DriverStats, stop models.PitStop) error {
+	record := []string{
+		fmt.Sprintf("%d", stats.SlotID),
+		stats.CarClass,
+		fmt.Sprintf("'%s'", stats.VehicleNumber),
+		stop.DriverName,
+		fmt.Sprintf("%d", stop.Number),
+		fmt.Sprintf("%d", stop.Lap),
+		fmt.Sprintf("%.1f", stop.EntryLapDistance),
+		stop.EntryAt.Format("15:04:05.000"),
+		stop.ExitAt.Format("15:04:05.000"),
+		fmt.Sprintf("%.2f", stop.PitLaneTime),
+		fmt.Sprintf("%.2f", stop.StationaryTime),
+		fmt.Sprintf("%.3f", stop.FuelBefore),
+		fmt.Sprintf("%.3f", stop.FuelAfter),
+		fmt.Sprintf("%t", stop.Serviced),
+		fmt.Sprintf("%t", stop.InGarage),
+		fmt.Sprintf("%t", stop.Incomplete),
+	}
+	if err := l.out.write(record); err != nil {
+		return fmt.Errorf("failed to write pit stop record: %w", err)
+	}
+	return nil
+}
+
+func (l *PitStopLogger) Close() error {
+	return l.out.close()
+}
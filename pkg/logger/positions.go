@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+// PositionLogger writes the position of every car at the end of each lap,
+// ready to be charted, and every position change as it happens.
+type PositionLogger struct {
+	chart   *appendWriter
+	changes *appendWriter
+}
+
+func NewPositionLogger(chartFilename string, changesFilename string) (*PositionLogger, error) {
+	chart, err := newAppendWriter(chartFilename, []string{
+		"Lap", "SlotID", "CarClass", "CarNumber", "DriverName", "Position", "ClassPosition",
+	})
+	if err != nil {
+		return nil, err
+	}
+	changes, err := newAppendWriter(changesFilename, []string{
+		"Time", "SlotID", "CarClass", "CarNumber", "DriverName",
+		"Lap", "LapDistance", "From", "To", "Kind", "Cars",
+	})
+	if err != nil {
+		chart.close()
+		return nil, err
+	}
+	return &PositionLogger{chart: chart, changes: changes}, nil
+}
+
+func (l *PositionLogger) LogPosition(stats *models.DriverStats, lap int, position int, classPosition int) error {
+	record := []string{
+		fmt.Sprintf("%d", lap),
+		fmt.Sprintf("%d", stats.SlotID),
+		stats.CarClass,
+		fmt.Sprintf("'%s'", stats.VehicleNumber),
+		stats.DriverName,
+		fmt.Sprintf("%d", position),
+		fmt.Sprintf("%d", classPosition),
+	}
+	if err := l.chart.write(record); err != nil {
+		return fmt.Errorf("failed to write position record: %w", err)
+	}
+	return nil
+}
+
+func (l *PositionLogger) LogChange(stats *models.DriverStats, change models.PositionChange) error {
+	record := []string{
+		change.Time.Format("15:04:05.000"),
+		fmt.Sprintf("%d", stats.SlotID),
+		stats.CarClass,
+		fmt.Sprintf("'%s'", stats.VehicleNumber),
+		stats.DriverName,
+		fmt.Sprintf("%d", change.Lap),
+		fmt.Sprintf("%.1f", change.LapDistance),
+		fmt.Sprintf("%d", change.From),
+		fmt.Sprintf("%d", change.To),
+		change.Kind,
+		strings.Join(change.Cars, ", "),
+	}
+	if err := l.changes.write(record); err != nil {
+		return fmt.Errorf("failed to write position change record: %w", err)
+	}
+	return nil
+}
+
+func (l *PositionLogger) Close() error {
+	chartErr := l.chart.close()
+	if err := l.changes.close(); err != nil {
+		return err
+	}
+	return chartErr
+}
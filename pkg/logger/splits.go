@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"fmt"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+type SplitLogger struct {
+	out *appendWriter
+}
+
+func NewSplitLogger(filename string) (*SplitLogger, error) {
+	header := []string{
+		"SlotID", "DriverName", "SteamID", "CarClass", "CarNumber",
+		"Lap", "Kind", "Name", "Speed", "Time", "Valid",
+	}
+	out, err := newAppendWriter(filename, header)
+	if err != nil {
+		return nil, err
+	}
+	return &SplitLogger{out: out}, nil
+}
+
+func (l *SplitLogger) LogSplits(stats *models.DriverStats, lap models.LapRecord) error {
+	for _, split := range lap.Splits {
+		speed, splitTime := "", ""
+		if split.Kind == models.SplitSpeedTrap {
+			speed = fmt.Sprintf("%.1f", split.Value)
+		} else {
+			splitTime = formatTime(split.Value)
+		}
+
+		record := []string{
+			fmt.Sprintf("%d", stats.SlotID),
+			lap.DriverName,
+			fmt.Sprintf("%d", lap.SteamID),
+			stats.CarClass,
+			fmt.Sprintf("'%s'", stats.VehicleNumber),
+			fmt.Sprintf("%d", lap.Lap),
+			split.Kind,
+			split.Name,
+			speed,
+			splitTime,
+			fmt.Sprintf("%t", lap.Valid),
+		}
+		if err := l.out.write(record); err != nil {
+			return fmt.Errorf("failed to write split record: %w", err)
+		}
+	}
+	return nil
+}
+
+func (l *SplitLogger) Close() error {
+	return l.out.close()
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"fmt"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+type StintLogger struct {
+	out *appendWriter
+}
+
+func NewStintLogger(filename string) (*StintLogger, error) {
+	header := []string{
+		"SlotID", "CarClass", "CarNumber", "DriverName", "SteamID",
+		"Stint", "StartLap", "EndLap", "Laps", "StintTime",
+		"BestLap", "AverageLap", "PitStops", "Finished",
+	}
+	out, err := newAppendWriter(filename, header)
+	if err != nil {
+		return nil, err
+	}
+	return &StintLogger{out: out}, nil
+}
+
+func (l *StintLogger) LogStint(stats *models.DriverStats, number int, stint models.Stint) error {
+	record := []string{
+		fmt.Sprintf("%d", stats.SlotID),
+		stats.CarClass,
+		fmt.Sprintf("'%s'", stats.VehicleNumber),
+		stint.DriverName,
+		fmt.Sprintf("%d", stint.SteamID),
+		fmt.Sprintf("%d", number),
+		fmt.Sprintf("%d", stint.StartLap),
+		fmt.Sprintf("%d", stint.EndLap),
+		fmt.Sprintf("%d", stint.Laps),
+		formatTime(stint.Duration().Seconds()),
+		formatTime(stint.BestLap),
+		formatTime(stint.AverageLap),
+		fmt.Sprintf("%d", stint.PitStops),
+		fmt.Sprintf("%t", stint.Finished),
+	}
+	if err := l.out.write(record); err != nil {
+		return fmt.Errorf("failed to write stint record: %w", err)
+	}
+	return nil
+}
+
+func (l *StintLogger) Close() error {
+	return l.out.close()
+}
@@ -0,0 +1,169 @@
+package mockserver
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+type Server struct {
+	mu        sync.Mutex
+	sim       *simulation
+	clients   map[*websocket.Conn]struct{}
+	upgrader  websocket.Upgrader
+	tick      time.Duration
+	timeScale float64
+	stopChan  chan struct{}
+	stopOnce  sync.Once
+}
+
+func NewServer(numCars int, seed int64) *Server {
+	return &Server{
+		sim:       newSimulation(numCars, seed),
+		clients:   make(map[*websocket.Conn]struct{}),
+		tick:      200 * time.Millisecond,
+		timeScale: 1,
+		stopChan:  make(chan struct{}),
+	}
+}
+
+func (s *Server) SetTick(tick time.Duration) {
+	s.tick = tick
+}
+
+func (s *Server) SetTimeScale(scale float64) {
+	s.timeScale = scale
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/websocket/controlpanel", s.handleWebSocket)
+	mux.HandleFunc("/rest/sessions/getAllVehicles", s.handleVehicles)
+	return mux
+}
+
+func (s *Server) ListenAndServe(host string, wsPort string, restPort string) error {
+	go s.Run()
+
+	errs := make(chan error, 2)
+	serve := func(port string) {
+		addr := host + ":" + port
+		log.Printf("Mock server listening on %s", addr)
+		errs <- http.ListenAndServe(addr, s.Handler())
+	}
+	go serve(wsPort)
+	if restPort != wsPort {
+		go serve(restPort)
+	}
+	return <-errs
+}
+
+func (s *Server) Run() {
+	ticker := time.NewTicker(s.tick)
+	defer ticker.Stop()
+
+	// Session info goes out once per simulated second, so flag changes are
+	// not skipped when the time scale is raised.
+	sessionEvery := int(1 / (s.tick.Seconds() * s.timeScale))
+	if sessionEvery < 1 {
+		sessionEvery = 1
+	}
+
+	for n := 0; ; n++ {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		s.sim.step(s.tick.Seconds() * s.timeScale)
+		standings := s.sim.standings()
+		session := s.sim.sessionInfo()
+		s.mu.Unlock()
+
+		if n%sessionEvery == 0 {
+			s.broadcast("sessionInfo", session)
+		}
+		s.broadcast("standings", standings)
+	}
+}
+
+func (s *Server) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.clients {
+		conn.Close()
+		delete(s.clients, conn)
+	}
+}
+
+func (s *Server) broadcast(msgType string, body interface{}) {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("Error marshaling %s: %v", msgType, err)
+		return
+	}
+	message, err := json.Marshal(models.WSMessage{Type: msgType, Body: raw})
+	if err != nil {
+		log.Printf("Error marshaling message: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.clients {
+		conn.SetWriteDeadline(time.Now().Add(time.Second))
+		if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+			log.Printf("Dropping mock client %s: %v", conn.RemoteAddr(), err)
+			conn.Close()
+			delete(s.clients, conn)
+		}
+	}
+}
+
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	s.clients[conn] = struct{}{}
+	s.mu.Unlock()
+	log.Printf("Mock client connected: %s", conn.RemoteAddr())
+
+	// Drain the connection so close frames are processed.
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				s.mu.Lock()
+				delete(s.clients, conn)
+				s.mu.Unlock()
+				conn.Close()
+				return
+			}
+		}
+	}()
+}
+
+func (s *Server) handleVehicles(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	vehicles := s.sim.vehicles()
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(vehicles); err != nil {
+		log.Printf("Error writing vehicles: %v", err)
+	}
+}
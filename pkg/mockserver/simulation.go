@@ -0,0 +1,433 @@
+package mockserver
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+const (
+	trackName    = "Mock Raceway"
+	trackLength  = 4200.0
+	pitEntry     = 4050.0
+	pitExit      = 250.0
+	pitLaneSpeed = 60 / 3.6
+	braking      = 15.0
+	sessionTime  = 3600.0
+	greenPhase   = 5
+	fcyPhase     = 6
+)
+
+type carClass struct {
+	name       string
+	baseLap    float64
+	fuelPerLap float64
+	models     []string
+}
+
+var classes = []carClass{
+	{name: "Hypercar", baseLap: 92, fuelPerLap: 0.085, models: []string{"Toyota GR010", "Ferrari 499P", "Porsche 963", "Cadillac V-Series.R"}},
+	{name: "LMP2", baseLap: 99, fuelPerLap: 0.075, models: []string{"Oreca 07"}},
+	{name: "GT3", baseLap: 108, fuelPerLap: 0.07, models: []string{"Porsche 911 GT3 R", "BMW M4 GT3", "Ferrari 296 GT3", "Aston Martin Vantage AMR"}},
+}
+
+var firstNames = []string{"Marek", "Kamui", "Antonio", "Sebastien", "Brendon", "Nyck", "Earl", "Mikkel", "Yifei", "Alessandro", "Kevin", "Robert"}
+var lastNames = []string{"Nowak", "Kobayashi", "Fuoco", "Buemi", "Hartley", "de Vries", "Bamber", "Jensen", "Ye", "Pier Guidi", "Estre", "Kubica"}
+
+// fcyStages is the sequence of yellow flag states of a full course yellow
+// and how long each lasts in seconds.
+var fcyStages = []struct {
+	state    string
+	duration float64
+}{
+	{"PENDING", 10},
+	{"PITS_CLOSED", 20},
+	{"PITS_OPEN", 30},
+	{"LAST_LAP", 25},
+	{"RESUME", 5},
+}
+
+type pitPhase int
+
+const (
+	pitNone pitPhase = iota
+	pitEntering
+	pitStopped
+	pitExiting
+)
+
+type car struct {
+	slotID        int
+	driverName    string
+	steamID       int64
+	class         carClass
+	vehicleID     string
+	vehicleModel  string
+	number        string
+	pace          float64
+	lapTarget     float64
+	lapsCompleted int
+	lapDistance   float64
+	timeIntoLap   float64
+	lapStartET    float64
+	sector1       float64
+	sector2       float64
+	lastLap       float64
+	lastSector1   float64
+	lastSector2   float64
+	bestLap       float64
+	bestLapS1     float64
+	bestLapS2     float64
+	speed         float64
+	fuel          float64
+	pit           pitPhase
+	pitStopLeft   float64
+	pitstops      int
+	pitLap        bool
+	position      int
+	grid          int
+	totalDistance float64
+}
+
+type simulation struct {
+	rng        *rand.Rand
+	cars       []*car
+	eventTime  float64
+	yellowLeft float64
+	yellowIdx  int
+	fcyStart   float64
+	fcyStage   int
+}
+
+func newSimulation(numCars int, seed int64) *simulation {
+	rng := rand.New(rand.NewSource(seed))
+	sim := &simulation{rng: rng, yellowIdx: -1}
+
+	for i := 0; i < numCars; i++ {
+		class := classes[i%len(classes)]
+		model := class.models[rng.Intn(len(class.models))]
+		c := &car{
+			slotID:       i,
+			driverName:   fmt.Sprintf("%s %s", firstNames[i%len(firstNames)], lastNames[(i*7+3)%len(lastNames)]),
+			steamID:      76561198000000000 + int64(i),
+			class:        class,
+			vehicleID:    fmt.Sprintf("mock_%s_%02d", class.name, i),
+			vehicleModel: model,
+			number:       fmt.Sprintf("%d", 2+i*3%97),
+			pace:         1 + rng.Float64()*0.02,
+			fuel:         1,
+			// Stagger the grid so cars do not all cross the line together.
+			lapDistance: trackLength - float64(i)*12,
+			lapStartET:  0,
+		}
+		c.lapTarget = c.class.baseLap * c.pace
+		sim.cars = append(sim.cars, c)
+	}
+	// One full course yellow after the first couple of green laps.
+	sim.fcyStart = 200 + rng.Float64()*30
+	sim.fcyStage = -1
+	sim.updatePositions()
+	for _, c := range sim.cars {
+		c.grid = c.position
+	}
+	return sim
+}
+
+func (s *simulation) step(dt float64) {
+	s.eventTime += dt
+	s.updateFlags(dt)
+
+	for _, c := range s.cars {
+		s.stepCar(c, dt)
+	}
+	s.updatePositions()
+}
+
+func (s *simulation) updateFlags(dt float64) {
+	s.fcyStage = -1
+	elapsed := s.eventTime - s.fcyStart
+	for i, stage := range fcyStages {
+		if elapsed < 0 {
+			break
+		}
+		if elapsed < stage.duration {
+			s.fcyStage = i
+			s.yellowIdx = -1
+			return
+		}
+		elapsed -= stage.duration
+	}
+
+	if s.yellowIdx >= 0 {
+		s.yellowLeft -= dt
+		if s.yellowLeft <= 0 {
+			s.yellowIdx = -1
+		}
+		return
+	}
+	// Roughly one local yellow every ten minutes.
+	if s.rng.Float64() < dt/600 {
+		s.yellowIdx = s.rng.Intn(3)
+		s.yellowLeft = 20 + s.rng.Float64()*40
+	}
+}
+
+func (s *simulation) stepCar(c *car, dt float64) {
+	if c.pit == pitStopped {
+		c.speed = 0
+		c.pitStopLeft -= dt
+		c.timeIntoLap += dt
+		if c.pitStopLeft <= 0 {
+			c.pit = pitExiting
+			c.fuel = 1
+			c.pitstops++
+		}
+		return
+	}
+
+	if c.pit == pitEntering || c.pit == pitExiting {
+		c.speed = pitLaneSpeed
+	} else {
+		// Speed varies along the lap so the max speed is higher than the average.
+		average := trackLength / c.lapTarget
+		target := average * (1 + 0.35*math.Sin(2*math.Pi*c.lapDistance/trackLength))
+		if s.fcyStage >= 0 {
+			target *= 0.6
+		} else if s.yellowIdx >= 0 && sectorOf(c.lapDistance) == s.yellowIdx {
+			target *= 0.8
+		}
+		// Slow down for a yellow gradually so it does not look like a spin.
+		c.speed = max(target, c.speed-braking*dt)
+	}
+
+	c.lapDistance += c.speed * dt
+	c.timeIntoLap += dt
+	c.fuel -= c.class.fuelPerLap * c.speed * dt / trackLength
+	if c.fuel < 0 {
+		c.fuel = 0
+	}
+
+	if c.pit == pitNone && !s.pitsClosed() && c.lapDistance >= pitEntry && c.lapDistance-c.speed*dt < pitEntry && c.fuel < c.class.fuelPerLap*1.5 {
+		c.pit = pitEntering
+		c.pitLap = true
+	}
+	if c.pit == pitEntering && c.lapDistance >= pitEntry+80 {
+		c.pit = pitStopped
+		c.pitStopLeft = 25 + s.rng.Float64()*10
+	}
+
+	if c.sector1 == 0 && c.lapDistance >= trackLength/3 {
+		c.sector1 = c.timeIntoLap
+	}
+	if c.sector2 == 0 && c.lapDistance >= 2*trackLength/3 {
+		c.sector2 = c.timeIntoLap
+	}
+
+	if c.lapDistance >= trackLength {
+		c.lapDistance -= trackLength
+		c.completeLap(s)
+	}
+
+	if c.pit == pitExiting && c.lapDistance >= pitExit && c.lapDistance < trackLength/2 {
+		c.pit = pitNone
+	}
+}
+
+func (c *car) completeLap(s *simulation) {
+	// Laps that started with the grid staggered are not timed.
+	if c.lapsCompleted > 0 {
+		c.lastLap = c.timeIntoLap
+		c.lastSector1 = c.sector1
+		c.lastSector2 = c.sector2
+		if !c.pitLap && (c.bestLap == 0 || c.lastLap < c.bestLap) {
+			c.bestLap = c.lastLap
+			c.bestLapS1 = c.sector1
+			c.bestLapS2 = c.sector2
+		}
+	}
+	c.lapsCompleted++
+	c.lapStartET = s.eventTime
+	c.timeIntoLap = 0
+	c.sector1 = 0
+	c.sector2 = 0
+	c.pitLap = c.pit != pitNone
+	c.lapTarget = c.class.baseLap * c.pace * (1 + (s.rng.Float64()-0.3)*0.015)
+}
+
+func (s *simulation) pitsClosed() bool {
+	return s.fcyStage >= 0 && fcyStages[s.fcyStage].state == "PITS_CLOSED"
+}
+
+func sectorOf(lapDistance float64) int {
+	sector := int(lapDistance / (trackLength / 3))
+	if sector > 2 {
+		sector = 2
+	}
+	return sector
+}
+
+func (s *simulation) updatePositions() {
+	for _, c := range s.cars {
+		c.totalDistance = float64(c.lapsCompleted)*trackLength + c.lapDistance
+	}
+	sorted := make([]*car, len(s.cars))
+	copy(sorted, s.cars)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].totalDistance > sorted[j].totalDistance
+	})
+	for i, c := range sorted {
+		c.position = i + 1
+	}
+}
+
+func (s *simulation) standings() []models.StandingsData {
+	sorted := make([]*car, len(s.cars))
+	copy(sorted, s.cars)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].position < sorted[j].position
+	})
+
+	gamePhase := "GREEN_FLAG"
+	if s.fcyStage >= 0 {
+		gamePhase = "FULL_COURSE_YELLOW"
+	}
+
+	standings := make([]models.StandingsData, 0, len(sorted))
+	for i, c := range sorted {
+		leader := sorted[0]
+		ahead := leader
+		if i > 0 {
+			ahead = sorted[i-1]
+		}
+		lapsBehindLeader, timeBehindLeader := gap(leader, c)
+		lapsBehindNext, timeBehindNext := gap(ahead, c)
+
+		angle := 2 * math.Pi * c.lapDistance / trackLength
+		flag := "green"
+		if s.fcyStage >= 0 || s.yellowIdx >= 0 && sectorOf(c.lapDistance) == s.yellowIdx {
+			flag = "yellow"
+		}
+
+		standings = append(standings, models.StandingsData{
+			BestLapSectorTime1: c.bestLapS1,
+			BestLapSectorTime2: c.bestLapS2,
+			BestLapTime:        c.bestLap,
+			BestSectorTime1:    c.bestLapS1,
+			BestSectorTime2:    c.bestLapS2,
+			CarClass:           c.class.name,
+			CarId:              c.vehicleID,
+			CarNumber:          c.number,
+			CarPosition: models.CarVector{
+				X: 700*math.Cos(angle) + 120*math.Cos(3*angle),
+				Z: 400*math.Sin(angle) + 60*math.Sin(2*angle),
+			},
+			CarVelocity:        models.CarVector{Velocity: c.speed},
+			CountLapFlag:       "COUNT_LAP_AND_TIME",
+			CurrentSectorTime1: c.sector1,
+			CurrentSectorTime2: c.sector2,
+			DriverName:         c.driverName,
+			EstimatedLapTime:   c.lapTarget,
+			FinishStatus:       "FSTAT_NONE",
+			Flag:               flag,
+			FuelFraction:       c.fuel,
+			FullTeamName:       fmt.Sprintf("Mock Racing #%s", c.number),
+			GamePhase:          gamePhase,
+			InGarageStall:      false,
+			LapDistance:        c.lapDistance,
+			LapStartET:         c.lapStartET,
+			LapsBehindLeader:   lapsBehindLeader,
+			LapsBehindNext:     lapsBehindNext,
+			LapsCompleted:      c.lapsCompleted,
+			LastLapTime:        c.lastLap,
+			LastSectorTime1:    c.lastSector1,
+			LastSectorTime2:    c.lastSector2,
+			PitLapDistance:     pitEntry,
+			PitState:           pitStateName(c.pit),
+			Pitstops:           c.pitstops,
+			Pitting:            c.pit != pitNone,
+			Player:             c.slotID == 0,
+			HasFocus:           c.slotID == 0,
+			Position:           c.position,
+			Qualification:      c.grid,
+			Sector:             fmt.Sprintf("SECTOR%d", sectorOf(c.lapDistance)+1),
+			SlotID:             c.slotID,
+			SteamID:            c.steamID,
+			TimeBehindLeader:   timeBehindLeader,
+			TimeBehindNext:     timeBehindNext,
+			TimeIntoLap:        c.timeIntoLap,
+			TrackEdge:          6,
+			UnderYellow:        flag == "yellow",
+			VehicleFilename:    c.vehicleID,
+			VehicleName:        fmt.Sprintf("%s #%s", c.vehicleModel, c.number),
+		})
+	}
+	return standings
+}
+
+func gap(ahead *car, c *car) (int, float64) {
+	distance := ahead.totalDistance - c.totalDistance
+	if distance <= 0 {
+		return 0, 0
+	}
+	laps := int(distance / trackLength)
+	average := trackLength / c.lapTarget
+	return laps, (distance - float64(laps)*trackLength) / average
+}
+
+func pitStateName(phase pitPhase) string {
+	switch phase {
+	case pitEntering:
+		return "ENTERING"
+	case pitStopped:
+		return "STOPPED"
+	case pitExiting:
+		return "EXITING"
+	}
+	return "NONE"
+}
+
+func (s *simulation) sessionInfo() models.SessionData {
+	sectorFlag := []string{"GREEN", "GREEN", "GREEN"}
+	if s.yellowIdx >= 0 {
+		sectorFlag[s.yellowIdx] = "YELLOW"
+	}
+	gamePhase := greenPhase
+	yellowState := "NONE"
+	if s.fcyStage >= 0 {
+		gamePhase = fcyPhase
+		yellowState = fcyStages[s.fcyStage].state
+	}
+	return models.SessionData{
+		AmbientTemp:      22.5,
+		CurrentEventTime: s.eventTime,
+		EndEventTime:     sessionTime,
+		GameMode:         "MOCK",
+		GamePhase:        gamePhase,
+		InRealtime:       true,
+		LapDistance:      trackLength,
+		MaxPlayers:       len(s.cars),
+		NumberOfVehicles: len(s.cars),
+		PlayerName:       s.cars[0].driverName,
+		SectorFlag:       sectorFlag,
+		ServerName:       "LMU Mock Server",
+		Session:          "RACE1",
+		TrackName:        trackName,
+		TrackTemp:        31.0,
+		YellowFlagState:  yellowState,
+	}
+}
+
+func (s *simulation) vehicles() []models.VehicleInfo {
+	vehicles := make([]models.VehicleInfo, 0, len(s.cars))
+	for _, c := range s.cars {
+		vehicles = append(vehicles, models.VehicleInfo{
+			Id:           c.vehicleID,
+			FullPathTree: fmt.Sprintf("%s, %s, %s", c.class.name, "Mock Racing", c.vehicleModel),
+			Number:       c.number,
+		})
+	}
+	return vehicles
+}
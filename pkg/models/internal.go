@@ -1,26 +1,249 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type DriverStats struct {
-	DriverName            string
-	VehicleName           string
-	VehicleModel          string
-	VehicleNumber         string
-	CarClass              string
-	SteamID               int64
-	MaxSpeed              float64
-	BestLapTime           float64
-	BestSector1           float64
-	BestSector2           float64
-	BestSector3           float64
-	MaxSpeedOnBestLap     float64
-	BestLapTimeCalculated float64
-	BestSector1Calculated float64
-	BestSector2Calculated float64
-	BestSector3Calculated float64
-	MaxSpeedOnBestLapCalc float64
-	Position              int
-	LapsCompleted         int
-	LastUpdate            time.Time
+	SlotID                int              `json:"slotID"`
+	CarID                 string           `json:"carID"`
+	DriverName            string           `json:"driverName"`
+	Drivers               []string         `json:"drivers"`
+	VehicleName           string           `json:"vehicleName"`
+	VehicleModel          string           `json:"vehicleModel"`
+	VehicleNumber         string           `json:"vehicleNumber"`
+	CarClass              string           `json:"carClass"`
+	SteamID               int64            `json:"steamID"`
+	MaxSpeed              float64          `json:"maxSpeed"`
+	BestLapTime           float64          `json:"bestLapTime"`
+	BestSector1           float64          `json:"bestSector1"`
+	BestSector2           float64          `json:"bestSector2"`
+	BestSector3           float64          `json:"bestSector3"`
+	MaxSpeedOnBestLap     float64          `json:"maxSpeedOnBestLap"`
+	BestLapTimeCalculated float64          `json:"bestLapTimeCalculated"`
+	BestSector1Calculated float64          `json:"bestSector1Calculated"`
+	BestSector2Calculated float64          `json:"bestSector2Calculated"`
+	BestSector3Calculated float64          `json:"bestSector3Calculated"`
+	MaxSpeedOnBestLapCalc float64          `json:"maxSpeedOnBestLapCalc"`
+	IdealSector1          float64          `json:"idealSector1"`
+	IdealSector2          float64          `json:"idealSector2"`
+	IdealSector3          float64          `json:"idealSector3"`
+	TheoreticalBestLap    float64          `json:"theoreticalBestLap"`
+	Position              int              `json:"position"`
+	LapsCompleted         int              `json:"lapsCompleted"`
+	LastUpdate            time.Time        `json:"lastUpdate"`
+	Laps                  []LapRecord      `json:"laps,omitempty"`
+	Stints                []Stint          `json:"stints"`
+	PitStops              []PitStop        `json:"pitStops"`
+	Fuel                  FuelEstimate     `json:"fuel"`
+	Delta                 LapDelta         `json:"delta"`
+	Incidents             int              `json:"incidents"`
+	StartPosition         int              `json:"startPosition"`
+	Overtakes             int              `json:"overtakes"`
+	TimesOvertaken        int              `json:"timesOvertaken"`
+	PositionChanges       []PositionChange `json:"positionChanges"`
+}
+
+func (s DriverStats) PositionsGained() int {
+	if s.StartPosition <= 0 || s.Position <= 0 {
+		return 0
+	}
+	return s.StartPosition - s.Position
+}
+
+const (
+	PositionOnTrack  = "on-track"
+	PositionPitCycle = "pit-cycle"
+	PositionOther    = "other"
+)
+
+// PositionChange records a car moving From one position To another. Cars
+// holds the numbers of the cars it passed or was passed by.
+type PositionChange struct {
+	Time        time.Time `json:"time"`
+	Lap         int       `json:"lap"`
+	LapDistance float64   `json:"lapDistance"`
+	From        int       `json:"from"`
+	To          int       `json:"to"`
+	Kind        string    `json:"kind"`
+	Cars        []string  `json:"cars"`
+}
+
+type LapDelta struct {
+	Delta        float64 `json:"delta"`
+	ReferenceLap float64 `json:"referenceLap"`
+	Active       bool    `json:"active"`
+}
+
+type FuelEstimate struct {
+	Fuel                 float64 `json:"fuel"`
+	LastLapUsage         float64 `json:"lastLapUsage"`
+	RollingAverage       float64 `json:"rollingAverage"`
+	StintAverage         float64 `json:"stintAverage"`
+	LapsRemaining        float64 `json:"lapsRemaining"`
+	SessionLapsRemaining float64 `json:"sessionLapsRemaining"`
+	FuelToFinish         float64 `json:"fuelToFinish"`
+}
+
+type LapRecord struct {
+	Lap          int        `json:"lap"`
+	DriverName   string     `json:"driverName"`
+	SteamID      int64      `json:"steamID"`
+	LapTime      float64    `json:"lapTime"`
+	Sector1      float64    `json:"sector1"`
+	Sector2      float64    `json:"sector2"`
+	Sector3      float64    `json:"sector3"`
+	MaxSpeed     float64    `json:"maxSpeed"`
+	Position     int        `json:"position"`
+	FuelFraction float64    `json:"fuelFraction"`
+	FuelUsed     float64    `json:"fuelUsed"`
+	Pitted       bool       `json:"pitted"`
+	Flag         string     `json:"flag"`
+	CountLapFlag string     `json:"countLapFlag"`
+	Valid        bool       `json:"valid"`
+	CompletedAt  time.Time  `json:"completedAt"`
+	Splits       []LapSplit `json:"splits"`
+}
+
+const (
+	SplitSpeedTrap  = "speedTrap"
+	SplitMiniSector = "miniSector"
+)
+
+// LapSplit is a speed trap reading in km/h or a mini-sector time in seconds.
+type LapSplit struct {
+	Kind  string  `json:"kind"`
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+type Frame struct {
+	Type     string          `json:"type"`
+	Body     json.RawMessage `json:"body"`
+	Received time.Time       `json:"received"`
+}
+
+type Stint struct {
+	DriverName string    `json:"driverName"`
+	SteamID    int64     `json:"steamID"`
+	StartLap   int       `json:"startLap"`
+	EndLap     int       `json:"endLap"`
+	StartedAt  time.Time `json:"startedAt"`
+	EndedAt    time.Time `json:"endedAt"`
+	Laps       int       `json:"laps"`
+	TimedLaps  int       `json:"timedLaps"`
+	BestLap    float64   `json:"bestLap"`
+	AverageLap float64   `json:"averageLap"`
+	PitStops   int       `json:"pitStops"`
+	Finished   bool      `json:"finished"`
+}
+
+func (s Stint) Duration() time.Duration {
+	return s.EndedAt.Sub(s.StartedAt)
+}
+
+type PitStop struct {
+	Number           int       `json:"number"`
+	Lap              int       `json:"lap"`
+	DriverName       string    `json:"driverName"`
+	EntryLapDistance float64   `json:"entryLapDistance"`
+	EntryAt          time.Time `json:"entryAt"`
+	ExitAt           time.Time `json:"exitAt"`
+	PitLaneTime      float64   `json:"pitLaneTime"`
+	StationaryTime   float64   `json:"stationaryTime"`
+	FuelBefore       float64   `json:"fuelBefore"`
+	FuelAfter        float64   `json:"fuelAfter"`
+	Serviced         bool      `json:"serviced"`
+	InGarage         bool      `json:"inGarage"`
+	Incomplete       bool      `json:"incomplete"`
+}
+
+type IdealSector struct {
+	Time          float64 `json:"time"`
+	SlotID        int     `json:"slotID"`
+	DriverName    string  `json:"driverName"`
+	VehicleNumber string  `json:"vehicleNumber"`
+}
+
+type IdealLap struct {
+	CarClass string      `json:"carClass"`
+	Sector1  IdealSector `json:"sector1"`
+	Sector2  IdealSector `json:"sector2"`
+	Sector3  IdealSector `json:"sector3"`
+}
+
+func (l IdealLap) LapTime() float64 {
+	if l.Sector1.Time <= 0 || l.Sector2.Time <= 0 || l.Sector3.Time <= 0 {
+		return 0
+	}
+	return l.Sector1.Time + l.Sector2.Time + l.Sector3.Time
+}
+
+const (
+	IncidentOffTrack = "off-track"
+	IncidentSpin     = "spin"
+	IncidentContact  = "contact"
+)
+
+type Incident struct {
+	Time          time.Time `json:"time"`
+	SlotID        int       `json:"slotID"`
+	DriverName    string    `json:"driverName"`
+	CarClass      string    `json:"carClass"`
+	VehicleNumber string    `json:"vehicleNumber"`
+	Kind          string    `json:"kind"`
+	Lap           int       `json:"lap"`
+	LapDistance   float64   `json:"lapDistance"`
+	Speed         float64   `json:"speed"`
+	Detail        string    `json:"detail"`
+	UnderYellow   bool      `json:"underYellow"`
+}
+
+const (
+	EventSectorYellow     = "sector-yellow"
+	EventFullCourseYellow = "full-course-yellow"
+	EventPhase            = "phase"
+	EventBlueFlag         = "blue-flag"
+	EventPenalty          = "penalty"
+	EventRetired          = "dnf"
+	EventDisqualified     = "dq"
+	EventLeadChange       = "lead-change"
+)
+
+// RaceEvent is a race control message. Session-wide events have SlotID -1.
+type RaceEvent struct {
+	Time          time.Time `json:"time"`
+	Kind          string    `json:"kind"`
+	SlotID        int       `json:"slotID"`
+	DriverName    string    `json:"driverName"`
+	CarClass      string    `json:"carClass"`
+	VehicleNumber string    `json:"vehicleNumber"`
+	Lap           int       `json:"lap"`
+	Message       string    `json:"message"`
+}
+
+type TrackPoint struct {
+	Distance float64 `json:"distance"`
+	X        float64 `json:"x"`
+	Z        float64 `json:"z"`
+}
+
+type TrackMap struct {
+	TrackName string       `json:"trackName"`
+	Length    float64      `json:"length"`
+	Points    []TrackPoint `json:"points"`
+}
+
+type Snapshot struct {
+	Time      time.Time
+	Session   *SessionData
+	Drivers   map[int]*StandingsData
+	Stats     map[int]*DriverStats
+	IdealLaps map[string]*IdealLap
+	TrackMap  *TrackMap
+	Incidents []Incident
+	Events    []RaceEvent
+	// Generation changes whenever the session is reset.
+	Generation int
 }
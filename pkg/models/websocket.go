@@ -1,8 +1,10 @@
 package models
 
+import "encoding/json"
+
 type WSMessage struct {
-	Type string      `json:"type"`
-	Body interface{} `json:"body"`
+	Type string          `json:"type"`
+	Body json.RawMessage `json:"body"`
 }
 
 type StandingsData struct {
@@ -64,8 +66,8 @@ type StandingsData struct {
 	UpgradePack        string     `json:"upgradePack"`
 	VehicleFilename    string     `json:"vehicleFilename"`
 	VehicleName        string     `json:"vehicleName"`
-	VehicleModel       string
-	VehicleNumber      string
+	VehicleModel       string     `json:"vehicleModel"`
+	VehicleNumber      string     `json:"vehicleNumber"`
 }
 
 type AttackMode struct {
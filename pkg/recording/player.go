@@ -0,0 +1,231 @@
+package recording
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+// ResetFrameType marks a synthetic frame emitted when the player rewinds;
+// everything derived from earlier frames must be discarded.
+const ResetFrameType = "replayReset"
+
+// Gaps longer than this, e.g. between two recording runs appended to the same
+// file, are collapsed so playback does not stall.
+const maxFrameGap = 10 * time.Second
+
+type Player struct {
+	filename string
+
+	mu               sync.Mutex
+	reader           *Reader
+	start            time.Time
+	skipped          time.Duration
+	lastRead         time.Duration
+	next             *models.Frame
+	nextOffset       time.Duration
+	position         time.Duration
+	speed            float64
+	paused           bool
+	seeking          bool
+	seekTo           time.Duration
+	fastForwardUntil time.Duration
+	resetPending     bool
+	anchorWall       time.Time
+	anchorOffset     time.Duration
+	closed           bool
+	wake             chan struct{}
+}
+
+func NewPlayer(filename string, speed float64) (*Player, error) {
+	reader, err := OpenReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &Player{
+		filename:   filename,
+		reader:     reader,
+		speed:      speed,
+		anchorWall: time.Now(),
+		wake:       make(chan struct{}, 1),
+	}, nil
+}
+
+func (p *Player) Next() (models.Frame, error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return models.Frame{}, io.EOF
+		}
+
+		if p.seeking {
+			p.seeking = false
+			if p.seekTo < p.position {
+				if err := p.rewind(); err != nil {
+					p.mu.Unlock()
+					return models.Frame{}, err
+				}
+				p.resetPending = true
+			}
+			p.fastForwardUntil = p.seekTo
+			p.position = p.seekTo
+			p.anchor()
+		}
+
+		if p.resetPending {
+			p.resetPending = false
+			p.mu.Unlock()
+			return models.Frame{Type: ResetFrameType, Received: time.Now()}, nil
+		}
+
+		if p.next == nil {
+			frame, err := p.reader.Read()
+			if err != nil {
+				p.mu.Unlock()
+				return frame, err
+			}
+			p.queue(frame)
+		}
+
+		wait := time.Duration(0)
+		if p.speed > 0 {
+			due := p.anchorWall.Add(time.Duration(float64(p.nextOffset-p.anchorOffset) / p.speed))
+			wait = time.Until(due)
+		}
+
+		if p.nextOffset <= p.fastForwardUntil || (!p.paused && wait <= 0) {
+			frame := *p.next
+			p.next = nil
+			if p.nextOffset > p.position {
+				p.position = p.nextOffset
+			}
+			p.mu.Unlock()
+			return frame, nil
+		}
+
+		paused := p.paused
+		p.mu.Unlock()
+
+		if paused {
+			<-p.wake
+			continue
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-p.wake:
+			timer.Stop()
+		}
+	}
+}
+
+func (p *Player) queue(frame models.Frame) {
+	if p.start.IsZero() {
+		p.start = frame.Received
+	}
+
+	offset := frame.Received.Sub(p.start) - p.skipped
+	gap := offset - p.lastRead
+	if gap > maxFrameGap {
+		p.skipped += gap - time.Second
+		offset = p.lastRead + time.Second
+	} else if gap < 0 {
+		p.skipped += gap
+		offset = p.lastRead
+	}
+
+	p.lastRead = offset
+	p.next = &frame
+	p.nextOffset = offset
+}
+
+func (p *Player) rewind() error {
+	reader, err := OpenReader(p.filename)
+	if err != nil {
+		return err
+	}
+	p.reader.Close()
+	p.reader = reader
+	p.start = time.Time{}
+	p.skipped = 0
+	p.lastRead = 0
+	p.next = nil
+	p.nextOffset = 0
+	return nil
+}
+
+func (p *Player) anchor() {
+	p.anchorWall = time.Now()
+	p.anchorOffset = p.position
+}
+
+func (p *Player) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Player) TogglePause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = !p.paused
+	p.anchor()
+	p.signal()
+}
+
+func (p *Player) Seek(delta time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	target := p.position + delta
+	if p.seeking {
+		target = p.seekTo + delta
+	}
+	if target < 0 {
+		target = 0
+	}
+	p.seekTo = target
+	p.seeking = true
+	p.signal()
+}
+
+func (p *Player) SetSpeed(speed float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.speed = speed
+	p.anchor()
+	p.signal()
+}
+
+func (p *Player) Speed() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.speed
+}
+
+func (p *Player) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+func (p *Player) Position() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.position
+}
+
+func (p *Player) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	p.signal()
+	return p.reader.Close()
+}
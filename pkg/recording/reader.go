@@ -0,0 +1,55 @@
+package recording
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+type Reader struct {
+	file    *os.File
+	gz      *gzip.Reader
+	decoder *json.Decoder
+}
+
+func OpenReader(filename string) (*Reader, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording file: %w", err)
+	}
+
+	gz, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read recording header: %w", err)
+	}
+
+	return &Reader{
+		file:    file,
+		gz:      gz,
+		decoder: json.NewDecoder(gz),
+	}, nil
+}
+
+func (r *Reader) Read() (models.Frame, error) {
+	var frame models.Frame
+	if err := r.decoder.Decode(&frame); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			// A recording cut short by a crash ends with a partial frame;
+			// everything before it is still usable.
+			return frame, io.EOF
+		}
+		return frame, fmt.Errorf("failed to decode frame: %w", err)
+	}
+	return frame, nil
+}
+
+func (r *Reader) Close() error {
+	r.gz.Close()
+	return r.file.Close()
+}
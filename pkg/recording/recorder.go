@@ -0,0 +1,58 @@
+package recording
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+type Recorder struct {
+	mu      sync.Mutex
+	file    *os.File
+	gz      *gzip.Writer
+	encoder *json.Encoder
+}
+
+func NewRecorder(filename string) (*Recorder, error) {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording file: %w", err)
+	}
+
+	// Every recorder appends its own gzip member, so a file written by several
+	// runs is still a single valid stream for the reader.
+	gz := gzip.NewWriter(file)
+	return &Recorder{
+		file:    file,
+		gz:      gz,
+		encoder: json.NewEncoder(gz),
+	}, nil
+}
+
+func (r *Recorder) Record(frame models.Frame) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.encoder.Encode(frame); err != nil {
+		return fmt.Errorf("failed to encode frame: %w", err)
+	}
+	if err := r.gz.Flush(); err != nil {
+		return fmt.Errorf("failed to flush recording: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.gz.Close(); err != nil {
+		r.file.Close()
+		return fmt.Errorf("failed to finish recording: %w", err)
+	}
+	return r.file.Close()
+}
@@ -0,0 +1,105 @@
+package recording
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+func writeRecording(t *testing.T, filename string, start time.Time, count int) {
+	t.Helper()
+	recorder, err := NewRecorder(filename)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	for i := 0; i < count; i++ {
+		frame := models.Frame{
+			Type:     "sessionInfo",
+			Body:     json.RawMessage(`{"trackName":"Le Mans"}`),
+			Received: start.Add(time.Duration(i) * time.Second),
+		}
+		if err := recorder.Record(frame); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestRecorderAppendsReadableStream(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "session.lmurec.gz")
+	start := time.Date(2025, 10, 10, 16, 0, 0, 0, time.UTC)
+	writeRecording(t, filename, start, 3)
+	writeRecording(t, filename, start.Add(time.Hour), 2)
+
+	reader, err := OpenReader(filename)
+	if err != nil {
+		t.Fatalf("OpenReader: %v", err)
+	}
+	defer reader.Close()
+
+	count := 0
+	for {
+		frame, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		if frame.Type != "sessionInfo" || string(frame.Body) != `{"trackName":"Le Mans"}` {
+			t.Fatalf("unexpected frame %+v", frame)
+		}
+		count++
+	}
+	if count != 5 {
+		t.Fatalf("read %d frames, want 5", count)
+	}
+}
+
+func TestPlayerSeekBackwardEmitsReset(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "session.lmurec.gz")
+	writeRecording(t, filename, time.Date(2025, 10, 10, 16, 0, 0, 0, time.UTC), 10)
+
+	player, err := NewPlayer(filename, 0)
+	if err != nil {
+		t.Fatalf("NewPlayer: %v", err)
+	}
+	defer player.Close()
+
+	for i := 0; i < 6; i++ {
+		if _, err := player.Next(); err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+	}
+	if got := player.Position(); got != 5*time.Second {
+		t.Fatalf("position %v, want 5s", got)
+	}
+
+	player.Seek(-3 * time.Second)
+	frame, err := player.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if frame.Type != ResetFrameType {
+		t.Fatalf("got frame %q after rewind, want %q", frame.Type, ResetFrameType)
+	}
+
+	remaining := 0
+	for {
+		if _, err := player.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		remaining++
+	}
+	if remaining != 10 {
+		t.Fatalf("replayed %d frames after rewind, want 10", remaining)
+	}
+}
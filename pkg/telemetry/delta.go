@@ -0,0 +1,81 @@
+package telemetry
+
+import (
+	"sort"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+type deltaSample struct {
+	distance float64
+	time     float64
+}
+
+type deltaTracker struct {
+	// fullLap is set once the lap in progress has been followed from the line,
+	// so its samples can become a reference.
+	fullLap      bool
+	samples      []deltaSample
+	reference    []deltaSample
+	referenceLap float64
+}
+
+// completeDeltaLap keeps the samples of a new best clean lap as the reference
+// for the live delta and starts collecting the next lap.
+func (m *Monitor) completeDeltaLap(lap models.LapRecord, tracker *deltaTracker) {
+	if tracker.fullLap && lap.Valid && !lap.Pitted && len(tracker.samples) > 1 &&
+		(tracker.referenceLap <= 0 || lap.LapTime < tracker.referenceLap) {
+		reference := tracker.samples
+		if m.session != nil && m.session.LapDistance > reference[len(reference)-1].distance {
+			reference = append(reference, deltaSample{distance: m.session.LapDistance, time: lap.LapTime})
+		}
+		tracker.reference = reference
+		tracker.referenceLap = lap.LapTime
+	}
+	tracker.samples = nil
+	tracker.fullLap = true
+}
+
+// updateDelta only follows the player car and the car the camera follows. A
+// lap that was not followed from the line cannot become the reference.
+func (m *Monitor) updateDelta(stats *models.DriverStats, driver *models.StandingsData, tracker *deltaTracker) {
+	stats.Delta = models.LapDelta{ReferenceLap: tracker.referenceLap}
+	if !driver.Player && !driver.HasFocus {
+		tracker.samples = nil
+		tracker.fullLap = false
+		return
+	}
+
+	if driver.LapDistance >= 0 {
+		n := len(tracker.samples)
+		if n == 0 || driver.LapDistance > tracker.samples[n-1].distance {
+			tracker.samples = append(tracker.samples, deltaSample{distance: driver.LapDistance, time: driver.TimeIntoLap})
+		}
+	}
+
+	if len(tracker.reference) < 2 || len(tracker.samples) == 0 || !tracker.fullLap {
+		return
+	}
+	current := tracker.samples[len(tracker.samples)-1]
+	stats.Delta.Delta = current.time - referenceTime(tracker.reference, current.distance)
+	stats.Delta.Active = true
+}
+
+// referenceTime interpolates the time the reference lap reached a distance.
+func referenceTime(reference []deltaSample, distance float64) float64 {
+	i := sort.Search(len(reference), func(i int) bool {
+		return reference[i].distance >= distance
+	})
+	if i == 0 {
+		if reference[0].distance <= 0 {
+			return reference[0].time
+		}
+		return reference[0].time * distance / reference[0].distance
+	}
+	if i == len(reference) {
+		return reference[len(reference)-1].time
+	}
+	before, after := reference[i-1], reference[i]
+	ratio := (distance - before.distance) / (after.distance - before.distance)
+	return before.time + ratio*(after.time-before.time)
+}
@@ -0,0 +1,174 @@
+package telemetry
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+const fullCourseYellowPhase = 6
+
+var gamePhases = map[int]string{
+	0: "Before session",
+	1: "Reconnaissance laps",
+	2: "Grid walk-through",
+	3: "Formation lap",
+	4: "Starting-light countdown",
+	5: "Green flag",
+	6: "Full course yellow",
+	7: "Session stopped",
+	8: "Session over",
+	9: "Session paused",
+}
+
+var yellowFlagStates = map[string]string{
+	"PENDING":      "Full course yellow",
+	"PITS_CLOSED":  "Full course yellow, pits closed",
+	"PIT_LEAD_LAP": "Full course yellow, pits open for lead lap cars",
+	"PITS_OPEN":    "Full course yellow, pits open",
+	"LAST_LAP":     "Full course yellow, last lap",
+	"RESUME":       "Full course yellow, resuming",
+	"RACE_HALT":    "Race halted",
+}
+
+type carEventState struct {
+	seen         bool
+	flag         string
+	penalties    int
+	finishStatus string
+}
+
+func (m *Monitor) detectSessionEvents(previous *models.SessionData, session *models.SessionData) {
+	// Going to full course yellow is reported from the yellow flag state below.
+	if session.GamePhase != previous.GamePhase && session.GamePhase != fullCourseYellowPhase {
+		phase, ok := gamePhases[session.GamePhase]
+		if !ok {
+			phase = fmt.Sprintf("Game phase %d", session.GamePhase)
+		}
+		m.addEvent(models.EventPhase, nil, phase)
+	}
+
+	if session.YellowFlagState != previous.YellowFlagState {
+		if isFullCourseYellow(session.YellowFlagState) {
+			message, ok := yellowFlagStates[session.YellowFlagState]
+			if !ok {
+				message = "Full course yellow (" + session.YellowFlagState + ")"
+			}
+			m.addEvent(models.EventFullCourseYellow, nil, message)
+		} else if isFullCourseYellow(previous.YellowFlagState) {
+			m.addEvent(models.EventFullCourseYellow, nil, "Full course yellow over")
+		}
+	}
+
+	for i, flag := range session.SectorFlag {
+		if i >= len(previous.SectorFlag) || previous.SectorFlag[i] == flag {
+			continue
+		}
+		switch {
+		case strings.EqualFold(flag, "GREEN"):
+			m.addEvent(models.EventSectorYellow, nil, fmt.Sprintf("Sector %d clear", i+1))
+		case strings.EqualFold(flag, "YELLOW"):
+			m.addEvent(models.EventSectorYellow, nil, fmt.Sprintf("Yellow flag in sector %d", i+1))
+		default:
+			m.addEvent(models.EventSectorYellow, nil, fmt.Sprintf("Sector %d %s", i+1, strings.ToLower(flag)))
+		}
+	}
+}
+
+func isFullCourseYellow(state string) bool {
+	return state != "" && state != "NONE" && state != "NOFLAG"
+}
+
+func (m *Monitor) detectCarEvents(driver *models.StandingsData, state *carEventState) {
+	previous := *state
+	state.seen = true
+	state.flag = driver.Flag
+	state.penalties = driver.Penalties
+	state.finishStatus = driver.FinishStatus
+	if !previous.seen {
+		return
+	}
+
+	if strings.EqualFold(driver.Flag, "blue") && !strings.EqualFold(previous.flag, "blue") {
+		m.addEvent(models.EventBlueFlag, driver, "Blue flag")
+	}
+	if driver.Penalties > previous.penalties {
+		m.addEvent(models.EventPenalty, driver, fmt.Sprintf("Penalty, %d outstanding", driver.Penalties))
+	}
+	if driver.FinishStatus != previous.finishStatus {
+		switch driver.FinishStatus {
+		case "FSTAT_DNF":
+			m.addEvent(models.EventRetired, driver, "Retired")
+		case "FSTAT_DQ":
+			m.addEvent(models.EventDisqualified, driver, "Disqualified")
+		}
+	}
+}
+
+// detectLeadChanges reports a new overall leader and new class leaders. The
+// overall leader's class lead is not reported again. Only cars in the current
+// standings count, so a car that left does not keep its position.
+func (m *Monitor) detectLeadChanges(standings []models.StandingsData) {
+	leaders := make(map[string]*models.StandingsData)
+	for i := range standings {
+		driver := &standings[i]
+		if driver.Position <= 0 {
+			continue
+		}
+		for _, class := range []string{"", driver.CarClass} {
+			leader, ok := leaders[class]
+			if !ok || driver.Position < leader.Position ||
+				driver.Position == leader.Position && driver.SlotID < leader.SlotID {
+				leaders[class] = driver
+			}
+		}
+	}
+
+	classes := make([]string, 0, len(leaders))
+	for class := range leaders {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	newOverallLeader := -1
+	for _, class := range classes {
+		leader := leaders[class]
+		previous, known := m.leaders[class]
+		m.leaders[class] = leader.SlotID
+		if !known || previous == leader.SlotID {
+			continue
+		}
+		if class == "" {
+			newOverallLeader = leader.SlotID
+			m.addEvent(models.EventLeadChange, leader, "Takes the lead")
+		} else if leader.SlotID != newOverallLeader {
+			m.addEvent(models.EventLeadChange, leader, "Takes the "+class+" lead")
+		}
+	}
+}
+
+func (m *Monitor) addEvent(kind string, driver *models.StandingsData, message string) {
+	event := models.RaceEvent{
+		Time:    m.now,
+		Kind:    kind,
+		SlotID:  -1,
+		Message: message,
+	}
+	if driver != nil {
+		event.SlotID = driver.SlotID
+		event.DriverName = driver.DriverName
+		event.CarClass = driver.CarClass
+		event.VehicleNumber = driver.VehicleNumber
+		event.Lap = driver.LapsCompleted + 1
+	}
+	m.events = append(m.events, event)
+
+	if m.eventLogger != nil {
+		if err := m.eventLogger.LogEvent(event); err != nil {
+			log.Printf("Error writing event CSV: %v", err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package telemetry
+
+import (
+	"math"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+const (
+	fuelWindow = 5
+	// Fuel rising by more than this between two frames means the car was refuelled.
+	refuelThreshold = 0.01
+	// rF2-based games report a huge lap limit for timed sessions.
+	maxLapLimit = 10000
+)
+
+type fuelTracker struct {
+	started        bool
+	lastFuel       float64
+	fuelAtLapStart float64
+	refuelled      bool
+	recentUse      []float64
+	recentLapTimes []float64
+	stintUsed      float64
+	stintLaps      int
+}
+
+func (m *Monitor) completeFuelLap(lap *models.LapRecord, driver *models.StandingsData, tracker *fuelTracker) {
+	used := tracker.fuelAtLapStart - driver.FuelFraction
+	clean := tracker.started && !tracker.refuelled && !lap.Pitted && used > 0
+
+	if clean {
+		lap.FuelUsed = used
+		tracker.recentUse = pushWindow(tracker.recentUse, used)
+		tracker.stintUsed += used
+		tracker.stintLaps++
+	}
+	if lap.Valid && !lap.Pitted {
+		tracker.recentLapTimes = pushWindow(tracker.recentLapTimes, lap.LapTime)
+	}
+
+	tracker.fuelAtLapStart = driver.FuelFraction
+	tracker.refuelled = false
+	tracker.started = true
+}
+
+func (m *Monitor) updateFuel(stats *models.DriverStats, driver *models.StandingsData, tracker *fuelTracker) {
+	if tracker.lastFuel > 0 && driver.FuelFraction > tracker.lastFuel+refuelThreshold {
+		tracker.refuelled = true
+		tracker.stintUsed = 0
+		tracker.stintLaps = 0
+	}
+	tracker.lastFuel = driver.FuelFraction
+
+	estimate := models.FuelEstimate{
+		Fuel:           driver.FuelFraction,
+		RollingAverage: average(tracker.recentUse),
+	}
+	if len(tracker.recentUse) > 0 {
+		estimate.LastLapUsage = tracker.recentUse[len(tracker.recentUse)-1]
+	}
+	if tracker.stintLaps > 0 {
+		estimate.StintAverage = tracker.stintUsed / float64(tracker.stintLaps)
+	}
+	if estimate.RollingAverage > 0 {
+		estimate.LapsRemaining = driver.FuelFraction / estimate.RollingAverage
+	}
+
+	lapTime := average(tracker.recentLapTimes)
+	if lapTime <= 0 {
+		lapTime = stats.BestLapTimeCalculated
+	}
+	if lapTime <= 0 {
+		lapTime = driver.EstimatedLapTime
+	}
+	estimate.SessionLapsRemaining = m.sessionLapsRemaining(driver, lapTime)
+	if estimate.RollingAverage > 0 && estimate.SessionLapsRemaining > 0 {
+		estimate.FuelToFinish = estimate.SessionLapsRemaining * estimate.RollingAverage
+	}
+
+	stats.Fuel = estimate
+}
+
+// sessionLapsRemaining estimates how many more laps a car will drive before
+// taking the flag, counting the unfinished part of the current lap.
+func (m *Monitor) sessionLapsRemaining(driver *models.StandingsData, lapTime float64) float64 {
+	if m.session == nil {
+		return 0
+	}
+
+	progress := 0.0
+	if m.session.LapDistance > 0 {
+		progress = math.Min(math.Max(driver.LapDistance/m.session.LapDistance, 0), 1)
+	}
+
+	if m.session.MaximumLaps > 0 && m.session.MaximumLaps < maxLapLimit {
+		left := float64(m.session.MaximumLaps-driver.LapsCompleted-driver.LapsBehindLeader) - progress
+		return math.Max(left, 0)
+	}
+
+	if m.session.EndEventTime <= 0 || lapTime <= 0 {
+		return 0
+	}
+	timeLeft := math.Max(m.session.EndEventTime-m.session.CurrentEventTime, 0)
+	// The car keeps going until it crosses the line after the clock runs out.
+	return math.Ceil(timeLeft/lapTime+progress) - progress
+}
+
+func pushWindow(values []float64, value float64) []float64 {
+	values = append(values, value)
+	if len(values) > fuelWindow {
+		values = values[len(values)-fuelWindow:]
+	}
+	return values
+}
+
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
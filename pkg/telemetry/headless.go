@@ -0,0 +1,66 @@
+package telemetry
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const headlessStatusInterval = 30 * time.Second
+
+// EnableHeadless makes Run log and record without the terminal UI, for
+// running as a background service.
+func (m *Monitor) EnableHeadless() {
+	m.display = nil
+}
+
+func (m *Monitor) runHeadless() error {
+	m.statusInterval = headlessStatusInterval
+	m.lastStatus = time.Now()
+
+	go func() {
+		m.consume()
+		close(m.consumeDone)
+	}()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	log.Println("Running headless")
+	select {
+	case sig := <-interrupt:
+		log.Printf("Received %v", sig)
+	case <-m.consumeDone:
+	}
+
+	m.stop()
+	<-m.consumeDone
+	m.cleanup()
+	return nil
+}
+
+// reportStatus logs a status line every statusInterval. It runs on the
+// consume goroutine, which owns the monitor state.
+func (m *Monitor) reportStatus() {
+	m.framesSinceStatus++
+	if m.statusInterval <= 0 || time.Since(m.lastStatus) < m.statusInterval {
+		return
+	}
+
+	if m.session == nil {
+		log.Printf("Status: waiting for session info, %d frames received", m.framesSinceStatus)
+	} else {
+		eventTime := time.Duration(m.session.CurrentEventTime * float64(time.Second)).Truncate(time.Second)
+		laps := 0
+		for _, stats := range m.driverStats {
+			laps += len(stats.Laps)
+		}
+		log.Printf("Status: %s - %s at %s, %d cars, %d laps recorded, %d frames received",
+			m.session.TrackName, m.session.Session, eventTime, len(m.drivers), laps, m.framesSinceStatus)
+	}
+	m.lastStatus = time.Now()
+	m.framesSinceStatus = 0
+}
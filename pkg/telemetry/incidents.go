@@ -0,0 +1,105 @@
+package telemetry
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+const (
+	// How far past the track edge a car must be to count as off track, in metres.
+	offTrackMargin = 1.0
+	// Braking or cornering never gets close to this; only impacts do (m/s²).
+	impactAcceleration = 60.0
+	// A car that comes down from racing speed to nearly a standstill within
+	// spinWindow, away from the pits, has most likely spun. Speeds in km/h.
+	spinFromSpeed = 80.0
+	spinStopSpeed = 20.0
+	spinWindow    = 5 * time.Second
+	// The same kind of incident is not reported again for a car within this time.
+	incidentCooldown = 5 * time.Second
+)
+
+type incidentTracker struct {
+	offTrack bool
+	// The last time the car was at racing speed, and whether it was under
+	// yellow then.
+	fastSpeed      float64
+	fastAt         time.Time
+	yellowWhenFast bool
+	lastByKind     map[string]time.Time
+}
+
+func (m *Monitor) updateIncidents(stats *models.DriverStats, driver *models.StandingsData, tracker *incidentTracker) {
+	speed := driver.CarVelocity.Velocity * 3.6
+
+	if inPitLane(driver) {
+		tracker.offTrack = false
+		tracker.fastAt = time.Time{}
+		return
+	}
+
+	offTrack := driver.TrackEdge > 0 && math.Abs(driver.PathLateral) > driver.TrackEdge+offTrackMargin
+	if offTrack && !tracker.offTrack {
+		m.addIncident(stats, driver, tracker, models.IncidentOffTrack,
+			fmt.Sprintf("%.1fm past the track edge", math.Abs(driver.PathLateral)-driver.TrackEdge))
+	}
+	tracker.offTrack = offTrack
+
+	acceleration := math.Hypot(driver.CarAcceleration.X, driver.CarAcceleration.Z)
+	if acceleration >= impactAcceleration {
+		m.addIncident(stats, driver, tracker, models.IncidentContact, fmt.Sprintf("%.1fg impact", acceleration/9.81))
+	}
+
+	if speed >= spinFromSpeed {
+		tracker.fastSpeed, tracker.fastAt, tracker.yellowWhenFast = speed, m.now, driver.UnderYellow
+		return
+	}
+	elapsed := m.now.Sub(tracker.fastAt)
+	if speed > spinStopSpeed || tracker.fastAt.IsZero() || elapsed > spinWindow {
+		return
+	}
+	tracker.fastAt = time.Time{}
+	// Cars that were already under yellow may be stopping behind an accident,
+	// so they only count when they also left the track.
+	if tracker.yellowWhenFast && !offTrack {
+		return
+	}
+	m.addIncident(stats, driver, tracker, models.IncidentSpin,
+		fmt.Sprintf("%.0f to %.0f km/h in %.1fs", tracker.fastSpeed, speed, elapsed.Seconds()))
+}
+
+func (m *Monitor) addIncident(stats *models.DriverStats, driver *models.StandingsData, tracker *incidentTracker, kind string, detail string) {
+	if tracker.lastByKind == nil {
+		tracker.lastByKind = make(map[string]time.Time)
+	}
+	if last, ok := tracker.lastByKind[kind]; ok && m.now.Sub(last) < incidentCooldown {
+		return
+	}
+	tracker.lastByKind[kind] = m.now
+
+	incident := models.Incident{
+		Time:          m.now,
+		SlotID:        stats.SlotID,
+		DriverName:    driver.DriverName,
+		CarClass:      stats.CarClass,
+		VehicleNumber: stats.VehicleNumber,
+		Kind:          kind,
+		Lap:           driver.LapsCompleted + 1,
+		LapDistance:   driver.LapDistance,
+		Speed:         driver.CarVelocity.Velocity * 3.6,
+		Detail:        detail,
+		UnderYellow:   driver.UnderYellow,
+	}
+	m.incidents = append(m.incidents, incident)
+	stats.Incidents++
+
+	if m.incidentLogger != nil {
+		if err := m.incidentLogger.LogIncident(incident); err != nil {
+			log.Printf("Error writing incident CSV: %v", err)
+		}
+	}
+}
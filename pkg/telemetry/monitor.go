@@ -3,184 +3,210 @@ package telemetry
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
-	"github.com/gorilla/websocket"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/api"
 	"github.com/mslomnicki/LMURacingTelemetry/pkg/logger"
 	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/recording"
 	"github.com/mslomnicki/LMURacingTelemetry/pkg/restclient"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/trackconfig"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/trackmap"
 	"github.com/mslomnicki/LMURacingTelemetry/pkg/ui"
 )
 
 type DriverLapState struct {
-	currentLapMaxSpeed   float64
-	lastCompletedLaps    int
-	lastValidTimeIntoLap float64
+	currentLapMaxSpeed     float64
+	currentLapPitted       bool
+	currentLapFlag         string
+	currentLapCountLapFlag string
+	lastCompletedLaps      int
+	lastPitstops           int
+	lastValidTimeIntoLap   float64
+	pit                    pitTracker
+	fuel                   fuelTracker
+	delta                  deltaTracker
+	splits                 splitTracker
+	incidents              incidentTracker
+	events                 carEventState
+	positions              positionTracker
 }
 
 type Monitor struct {
-	conn            *websocket.Conn
-	display         *ui.Display
-	csvLogger       *logger.CSVLogger
-	drivers         map[string]*models.StandingsData
-	driverStats     map[string]*models.DriverStats
-	lapStates       map[string]*DriverLapState
-	session         *models.SessionData
-	reconnecting    bool
-	stopChan        chan struct{}
-	vehicles        map[string]models.VehicleInfo
-	host            string
-	wsPort          string
-	restPort        string
-	lastVehicleLoad time.Time
+	source            Source
+	display           *ui.Display
+	api               *api.Server
+	csvLogger         *logger.CSVLogger
+	rewoundLogger     *logger.CSVLogger
+	lapLogger         *logger.LapLogger
+	stintLogger       *logger.StintLogger
+	pitStopLogger     *logger.PitStopLogger
+	splitLogger       *logger.SplitLogger
+	incidentLogger    *logger.IncidentLogger
+	eventLogger       *logger.EventLogger
+	positionLogger    *logger.PositionLogger
+	recorder          *recording.Recorder
+	drivers           map[int]*models.StandingsData
+	driverStats       map[int]*models.DriverStats
+	lapStates         map[int]*DriverLapState
+	idealLaps         map[string]*models.IdealLap
+	incidents         []models.Incident
+	events            []models.RaceEvent
+	leaders           map[string]int
+	generation        int
+	trackMap          *models.TrackMap
+	trackMapBuilder   *trackmap.Builder
+	trackConfig       trackconfig.Config
+	session           *models.SessionData
+	stopChan          chan struct{}
+	stopOnce          sync.Once
+	consumeDone       chan struct{}
+	vehicles          map[string]models.VehicleInfo
+	host              string
+	restPort          string
+	lastVehicleLoad   time.Time
+	now               time.Time
+	statusInterval    time.Duration
+	lastStatus        time.Time
+	framesSinceStatus int
 }
 
 func NewMonitor(host string, wsPort string, restPort string) *Monitor {
+	m := NewMonitorWithSource(NewWebSocketSource(host, wsPort))
+	m.host = host
+	m.restPort = restPort
+	return m
+}
+
+func NewMonitorWithSource(source Source) *Monitor {
 	return &Monitor{
+		source:      source,
 		display:     ui.NewDisplay(),
-		drivers:     make(map[string]*models.StandingsData),
-		driverStats: make(map[string]*models.DriverStats),
-		lapStates:   make(map[string]*DriverLapState),
+		drivers:     make(map[int]*models.StandingsData),
+		driverStats: make(map[int]*models.DriverStats),
+		lapStates:   make(map[int]*DriverLapState),
+		idealLaps:   make(map[string]*models.IdealLap),
+		leaders:     make(map[string]int),
 		stopChan:    make(chan struct{}),
-		host:        host,
-		wsPort:      wsPort,
-		restPort:    restPort,
+		consumeDone: make(chan struct{}),
 	}
 }
 
-func (m *Monitor) websocketURL() string {
-	return fmt.Sprintf("ws://%s:%s/websocket/controlpanel", m.host, m.wsPort)
-}
-
-func (m *Monitor) Connect() error {
-	var err error
-	url := m.websocketURL()
-	m.conn, _, err = websocket.DefaultDialer.Dial(url, nil)
+func (m *Monitor) EnableRecording(filename string) error {
+	recorder, err := recording.NewRecorder(filename)
 	if err != nil {
-		return fmt.Errorf("failed to connect to WebSocket: %w", err)
+		return err
 	}
-	log.Printf("Connected to WebSocket: %s", url)
+	m.recorder = recorder
+	log.Printf("Recording session to %s", filename)
 	return nil
 }
 
-func (m *Monitor) connectWithRetry() {
-	backoff := time.Second
-	maxBackoff := 30 * time.Second
-
-	for {
-		select {
-		case <-m.stopChan:
-			return
-		default:
-		}
-
-		if !m.reconnecting {
-			log.Printf("Attempting to connect to WebSocket...")
-		} else {
-			log.Printf("Attempting to reconnect to WebSocket...")
-		}
-
-		if err := m.Connect(); err != nil {
-			if !m.reconnecting {
-				log.Printf("Initial connection failed: %v. Retrying in %v...", err, backoff)
-			} else {
-				log.Printf("Reconnection failed: %v. Retrying in %v...", err, backoff)
-			}
-
-			select {
-			case <-time.After(backoff):
-				backoff = time.Duration(float64(backoff) * 1.5)
-				if backoff > maxBackoff {
-					backoff = maxBackoff
-				}
-			case <-m.stopChan:
-				return
-			}
-			continue
-		}
-
-		if m.reconnecting {
-			log.Printf("Reconnected successfully!")
-			m.reconnecting = false
-		}
-
-		m.listenForMessages()
-
-		if m.conn != nil {
-			err := m.conn.Close()
-			if err != nil {
-				log.Printf("Error closing WebSocket connection: %v", err)
-			}
-		}
-
-		select {
-		case <-m.stopChan:
-			return
-		default:
-			m.reconnecting = true
-			backoff = time.Second
-		}
+// EnableAPI serves the monitor state as JSON on addr while the monitor runs.
+func (m *Monitor) EnableAPI(addr string) error {
+	server := api.NewServer()
+	if err := server.Listen(addr); err != nil {
+		return err
 	}
+	m.api = server
+	log.Printf("Serving API on http://%s/api/", server.Addr())
+	return nil
 }
 
 func (m *Monitor) Run() error {
+	if m.display == nil {
+		return m.runHeadless()
+	}
 	m.display.Setup()
 
-	go m.connectWithRetry()
+	if playback, ok := m.source.(PlaybackSource); ok {
+		m.bindPlaybackKeys(playback)
+		go m.updatePlaybackStatus(playback)
+	}
+
+	go func() {
+		m.consume()
+		close(m.consumeDone)
+	}()
 
 	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
 
 	go func() {
-		<-interrupt
-		close(m.stopChan)
-		m.cleanup()
-		m.display.Stop()
+		select {
+		case <-interrupt:
+			m.display.Stop()
+		case <-m.stopChan:
+		}
 	}()
 
-	return m.display.Run()
+	err := m.display.Run()
+
+	m.stop()
+	<-m.consumeDone
+	m.cleanup()
+	return err
 }
 
-func (m *Monitor) listenForMessages() {
-	defer func() {
-		if m.conn != nil {
-			err := m.conn.Close()
-			if err != nil {
-				log.Printf("Error closing WebSocket connection: %v", err)
-			}
-		}
-	}()
+func (m *Monitor) stop() {
+	m.stopOnce.Do(func() {
+		close(m.stopChan)
+	})
+	if err := m.source.Close(); err != nil {
+		log.Printf("Error closing telemetry source: %v", err)
+	}
+}
 
+func (m *Monitor) consume() {
 	for {
-		select {
-		case <-m.stopChan:
+		frame, err := m.source.Next()
+		if err == io.EOF {
+			log.Println("Telemetry source finished")
 			return
-		default:
 		}
-
-		_, message, err := m.conn.ReadMessage()
 		if err != nil {
-			log.Printf("WebSocket read error: %v", err)
+			log.Printf("Telemetry source error: %v", err)
 			return
 		}
 
-		var wsMsg models.WSMessage
-		if err := json.Unmarshal(message, &wsMsg); err != nil {
-			log.Printf("Error unmarshaling WebSocket message: %v", err)
-			continue
-		}
+		m.handleFrame(frame)
+	}
+}
 
-		bodyBytes, err := json.Marshal(wsMsg.Body)
-		if err != nil {
-			log.Printf("Error marshaling message body: %v", err)
-			continue
-		}
+func (m *Monitor) handleFrame(frame models.Frame) {
+	m.now = frame.Received
+	if m.now.IsZero() {
+		m.now = time.Now()
+	}
+
+	if frame.Type == recording.ResetFrameType {
+		// The replay continues from an earlier point of the same recording,
+		// so its files are written again rather than appended to.
+		m.rewoundLogger = m.csvLogger
+		m.resetSession()
+		return
+	}
 
-		m.handleMessage(wsMsg.Type, bodyBytes)
+	m.recordFrame(frame)
+	m.handleMessage(frame.Type, frame.Body)
+	m.reportStatus()
+}
+
+func (m *Monitor) recordFrame(frame models.Frame) {
+	if m.recorder == nil {
+		return
+	}
+	if err := m.recorder.Record(frame); err != nil {
+		log.Printf("Error recording frame: %v", err)
 	}
 }
 
@@ -191,14 +217,19 @@ func (m *Monitor) handleMessage(msgType string, body json.RawMessage) {
 	case "sessionInfo":
 		m.handleSessionInfo(body)
 	case "standingsHistory":
+	case "vehicles":
+		m.handleVehicles(body)
 	default:
 		log.Printf("Unsupported message type: %s, body: %s", msgType, string(body))
 	}
 
-	m.updateDisplay()
+	m.publishSnapshot()
 }
 
 func (m *Monitor) loadVehicles() error {
+	if m.host == "" {
+		return fmt.Errorf("no REST endpoint configured")
+	}
 	if time.Since(m.lastVehicleLoad) < time.Minute {
 		return nil
 	}
@@ -209,9 +240,47 @@ func (m *Monitor) loadVehicles() error {
 	}
 	m.vehicles = vehicles
 	m.lastVehicleLoad = time.Now()
+
+	if m.recorder != nil {
+		list := make([]models.VehicleInfo, 0, len(vehicles))
+		for _, v := range vehicles {
+			list = append(list, v)
+		}
+		body, err := json.Marshal(list)
+		if err != nil {
+			log.Printf("Error marshaling vehicles for recording: %v", err)
+		} else {
+			m.recordFrame(models.Frame{Type: "vehicles", Body: body, Received: time.Now()})
+		}
+	}
 	return nil
 }
 
+func (m *Monitor) handleVehicles(body json.RawMessage) {
+	var list []models.VehicleInfo
+	if err := json.Unmarshal(body, &list); err != nil {
+		log.Printf("Error unmarshaling vehicles: %v", err)
+		return
+	}
+
+	m.vehicles = make(map[string]models.VehicleInfo)
+	for _, v := range list {
+		m.vehicles[v.Id] = v
+	}
+
+	for key, driver := range m.drivers {
+		stats, exists := m.driverStats[key]
+		if !exists {
+			continue
+		}
+		if v, ok := m.vehicles[driver.VehicleFilename]; ok {
+			stats.VehicleModel, stats.VehicleNumber = getVehicleModelAndNumber(&v)
+			driver.VehicleModel = stats.VehicleModel
+			driver.VehicleNumber = stats.VehicleNumber
+		}
+	}
+}
+
 func (m *Monitor) handleStandings(body json.RawMessage) {
 	var standings []models.StandingsData
 	if err := json.Unmarshal(body, &standings); err != nil {
@@ -220,11 +289,36 @@ func (m *Monitor) handleStandings(body json.RawMessage) {
 	}
 
 	for _, driver := range standings {
-		key := driver.DriverName
+		key := driver.SlotID
+		if previous, exists := m.drivers[key]; exists && previous.VehicleFilename != driver.VehicleFilename {
+			log.Printf("Slot %d reused by %s (%s), dropping state of %s", key, driver.DriverName, driver.VehicleName, previous.VehicleName)
+			m.dropCar(key, previous)
+		}
 		m.drivers[key] = &driver
 		m.updateDriverStats(&driver)
+		m.sampleTrackMap(&driver)
 		m.logDriverData(&driver)
 	}
+	m.updatePositions()
+	m.detectLeadChanges(standings)
+}
+
+// dropCar forgets a car that left its slot. Its open stint and pit stop are
+// ended and written first, the pit stop marked as incomplete, and its row
+// stays in the session summary.
+func (m *Monitor) dropCar(key int, last *models.StandingsData) {
+	if stats := m.driverStats[key]; stats != nil {
+		m.endStint(stats, last)
+		if lapState := m.lapStates[key]; lapState != nil && lapState.pit.stop != nil {
+			lapState.pit.stop.Incomplete = true
+			m.finishPitStop(stats, last, &lapState.pit)
+		}
+	}
+	if m.csvLogger != nil {
+		m.csvLogger.RemoveDriver(key)
+	}
+	delete(m.driverStats, key)
+	delete(m.lapStates, key)
 }
 
 func (m *Monitor) handleSessionInfo(body json.RawMessage) {
@@ -240,32 +334,141 @@ func (m *Monitor) handleSessionInfo(body json.RawMessage) {
 	}
 
 	if sessionChanged {
-		if m.csvLogger != nil {
-			if err := m.csvLogger.Close(); err != nil {
-				log.Printf("Error closing previous CSV logger: %v", err)
-			}
-			m.csvLogger = nil
-			log.Println("Previous CSV logger closed due to session change")
-		}
-		m.drivers = make(map[string]*models.StandingsData)
-		m.driverStats = make(map[string]*models.DriverStats)
-		m.lapStates = make(map[string]*DriverLapState)
+		m.resetSession()
 		log.Println("All driver data and stats reset due to session change")
+	} else {
+		m.detectSessionEvents(m.session, &session)
 	}
 
 	m.session = &session
+	m.loadTrackMap()
 
 	if m.csvLogger == nil && m.session != nil {
-		var err error
+		m.openSessionLoggers()
+	}
+}
+
+func (m *Monitor) openSessionLoggers() {
+	var err error
+	if m.rewoundLogger != nil && m.rewoundLogger.Logs(m.session) {
+		m.csvLogger, err = m.rewoundLogger.Restart()
+	} else {
 		m.csvLogger, err = logger.NewCSVLogger(m.session)
+	}
+	m.rewoundLogger = nil
+	if err != nil {
+		log.Printf("Error initializing CSV logger: %v", err)
+		return
+	}
+	log.Printf("CSV logging initialized for %s - %s", m.session.TrackName, m.session.Session)
+
+	m.lapLogger, err = logger.NewLapLogger(m.csvLogger.SessionFilename("laps"))
+	if err != nil {
+		log.Printf("Error initializing lap logger: %v", err)
+	}
+	m.stintLogger, err = logger.NewStintLogger(m.csvLogger.SessionFilename("stints"))
+	if err != nil {
+		log.Printf("Error initializing stint logger: %v", err)
+	}
+	m.pitStopLogger, err = logger.NewPitStopLogger(m.csvLogger.SessionFilename("pitstops"))
+	if err != nil {
+		log.Printf("Error initializing pit stop logger: %v", err)
+	}
+	m.incidentLogger, err = logger.NewIncidentLogger(m.csvLogger.SessionFilename("incidents"))
+	if err != nil {
+		log.Printf("Error initializing incident logger: %v", err)
+	}
+	m.eventLogger, err = logger.NewEventLogger(m.csvLogger.SessionFilename("events"))
+	if err != nil {
+		log.Printf("Error initializing event logger: %v", err)
+	}
+	m.positionLogger, err = logger.NewPositionLogger(m.csvLogger.SessionFilename("positions"), m.csvLogger.SessionFilename("position_changes"))
+	if err != nil {
+		log.Printf("Error initializing position logger: %v", err)
+	}
+	if track := m.trackDefinition(); len(track.SpeedTraps) > 0 || len(track.MiniSectors) > 0 {
+		m.splitLogger, err = logger.NewSplitLogger(m.csvLogger.SessionFilename("splits"))
 		if err != nil {
-			log.Printf("Error initializing CSV logger: %v", err)
+			log.Printf("Error initializing split logger: %v", err)
+		}
+	}
+}
+
+func (m *Monitor) closeSessionLoggers() {
+	if m.csvLogger != nil {
+		if err := m.csvLogger.Close(); err != nil {
+			log.Printf("Error closing CSV logger: %v", err)
 		} else {
-			log.Printf("CSV logging initialized for %s - %s", m.session.TrackName, m.session.Session)
+			log.Println("CSV logging stopped")
+		}
+		m.csvLogger = nil
+	}
+
+	if m.lapLogger != nil {
+		if err := m.lapLogger.Close(); err != nil {
+			log.Printf("Error closing lap logger: %v", err)
+		}
+		m.lapLogger = nil
+	}
+
+	if m.stintLogger != nil {
+		m.logOpenStints()
+		if err := m.stintLogger.Close(); err != nil {
+			log.Printf("Error closing stint logger: %v", err)
+		}
+		m.stintLogger = nil
+	}
+
+	if m.pitStopLogger != nil {
+		m.finishOpenPitStops()
+		if err := m.pitStopLogger.Close(); err != nil {
+			log.Printf("Error closing pit stop logger: %v", err)
+		}
+		m.pitStopLogger = nil
+	}
+
+	if m.incidentLogger != nil {
+		if err := m.incidentLogger.Close(); err != nil {
+			log.Printf("Error closing incident logger: %v", err)
+		}
+		m.incidentLogger = nil
+	}
+
+	if m.eventLogger != nil {
+		if err := m.eventLogger.Close(); err != nil {
+			log.Printf("Error closing event logger: %v", err)
+		}
+		m.eventLogger = nil
+	}
+
+	if m.positionLogger != nil {
+		if err := m.positionLogger.Close(); err != nil {
+			log.Printf("Error closing position logger: %v", err)
+		}
+		m.positionLogger = nil
+	}
+
+	if m.splitLogger != nil {
+		if err := m.splitLogger.Close(); err != nil {
+			log.Printf("Error closing split logger: %v", err)
 		}
+		m.splitLogger = nil
 	}
 }
 
+func (m *Monitor) resetSession() {
+	m.closeSessionLoggers()
+	m.session = nil
+	m.drivers = make(map[int]*models.StandingsData)
+	m.driverStats = make(map[int]*models.DriverStats)
+	m.lapStates = make(map[int]*DriverLapState)
+	m.idealLaps = make(map[string]*models.IdealLap)
+	m.incidents = nil
+	m.events = nil
+	m.leaders = make(map[string]int)
+	m.generation++
+}
+
 func getVehicleModelAndNumber(vinfo *models.VehicleInfo) (string, string) {
 	if vinfo == nil {
 		return "", ""
@@ -278,12 +481,13 @@ func getVehicleModelAndNumber(vinfo *models.VehicleInfo) (string, string) {
 }
 
 func (m *Monitor) updateDriverStats(driver *models.StandingsData) {
-	key := driver.DriverName
+	key := driver.SlotID
 
 	lapState, lapStateExists := m.lapStates[key]
 	if !lapStateExists {
 		lapState = &DriverLapState{
 			lastCompletedLaps: driver.LapsCompleted,
+			lastPitstops:      driver.Pitstops,
 		}
 		m.lapStates[key] = lapState
 	}
@@ -301,7 +505,10 @@ func (m *Monitor) updateDriverStats(driver *models.StandingsData) {
 	stats, exists := m.driverStats[key]
 	if !exists {
 		stats = &models.DriverStats{
+			SlotID:      driver.SlotID,
+			CarID:       driver.CarId,
 			DriverName:  driver.DriverName,
+			Drivers:     []string{driver.DriverName},
 			VehicleName: driver.VehicleName,
 			CarClass:    driver.CarClass,
 		}
@@ -326,6 +533,18 @@ func (m *Monitor) updateDriverStats(driver *models.StandingsData) {
 		stats.VehicleModel = model
 		stats.VehicleNumber = number
 		m.driverStats[key] = stats
+		m.startStint(stats, driver)
+	}
+
+	if stats.DriverName != driver.DriverName {
+		log.Printf("Driver change on car #%s (slot %d): %s -> %s", stats.VehicleNumber, key, stats.DriverName, driver.DriverName)
+		if !slices.Contains(stats.Drivers, driver.DriverName) {
+			stats.Drivers = append(stats.Drivers, driver.DriverName)
+		}
+		m.endStint(stats, driver)
+		m.startStint(stats, driver)
+		// The live delta is against the best lap of the driver in the car.
+		lapState.delta = deltaTracker{}
 	}
 
 	stats.DriverName = driver.DriverName
@@ -333,7 +552,7 @@ func (m *Monitor) updateDriverStats(driver *models.StandingsData) {
 	stats.CarClass = driver.CarClass
 	stats.Position = driver.Position
 	stats.LapsCompleted = driver.LapsCompleted
-	stats.LastUpdate = time.Now()
+	stats.LastUpdate = m.now
 	stats.SteamID = driver.SteamID
 	driver.VehicleModel = stats.VehicleModel
 	driver.VehicleNumber = stats.VehicleNumber
@@ -349,6 +568,16 @@ func (m *Monitor) updateDriverStats(driver *models.StandingsData) {
 	}
 
 	if driver.LapsCompleted > lapState.lastCompletedLaps {
+		lap := m.newLapRecord(driver, lapState)
+		m.completeFuelLap(&lap, driver, &lapState.fuel)
+		m.completeDeltaLap(lap, &lapState.delta)
+		m.completeSplits(&lap, &lapState.splits)
+		stats.Laps = append(stats.Laps, lap)
+		addLapToStint(stats, lap)
+		m.updateIdealSectors(stats, lap)
+		m.logLap(stats, lap)
+		m.logSplits(stats, lap)
+
 		if driver.LastLapTime > 0 && (stats.BestLapTimeCalculated == 0 || driver.LastLapTime < stats.BestLapTimeCalculated) {
 			stats.MaxSpeedOnBestLapCalc = lapState.currentLapMaxSpeed
 			stats.BestLapTimeCalculated = driver.LastLapTime
@@ -358,48 +587,156 @@ func (m *Monitor) updateDriverStats(driver *models.StandingsData) {
 			stats.BestSector3Calculated = driver.LastLapTime - driver.LastSectorTime2
 		}
 		lapState.currentLapMaxSpeed = currentSpeed
+		lapState.currentLapPitted = false
+		lapState.currentLapFlag = ""
 		lapState.lastCompletedLaps = driver.LapsCompleted
 	}
 
+	if driver.Pitting || driver.InGarageStall || (driver.PitState != "" && driver.PitState != "NONE") {
+		lapState.currentLapPitted = true
+	}
+	if driver.Flag != "" && driver.Flag != "green" {
+		lapState.currentLapFlag = driver.Flag
+	}
+	lapState.currentLapCountLapFlag = driver.CountLapFlag
+	m.updateStint(stats, driver, lapState)
+	m.updatePitStops(stats, driver, &lapState.pit)
+	m.updateIncidents(stats, driver, &lapState.incidents)
+	m.detectCarEvents(driver, &lapState.events)
+	m.updateFuel(stats, driver, &lapState.fuel)
+	m.updateDelta(stats, driver, &lapState.delta)
+	m.updateSplits(driver, &lapState.splits)
+
 	stats.BestLapTime = driver.BestLapTime
 	stats.BestSector1 = driver.BestLapSectorTime1
 	stats.BestSector2 = driver.BestLapSectorTime2 - driver.BestLapSectorTime1
 	stats.BestSector3 = driver.BestLapTime - driver.BestLapSectorTime2
 }
 
+func (m *Monitor) newLapRecord(driver *models.StandingsData, lapState *DriverLapState) models.LapRecord {
+	flag := lapState.currentLapFlag
+	if flag == "" {
+		flag = "green"
+	}
+	// The frame that completes a lap already carries the flag of the next one.
+	countLapFlag := lapState.currentLapCountLapFlag
+	if countLapFlag == "" {
+		countLapFlag = driver.CountLapFlag
+	}
+
+	lap := models.LapRecord{
+		Lap:          driver.LapsCompleted,
+		DriverName:   driver.DriverName,
+		SteamID:      driver.SteamID,
+		MaxSpeed:     lapState.currentLapMaxSpeed,
+		Position:     driver.Position,
+		FuelFraction: driver.FuelFraction,
+		Pitted:       lapState.currentLapPitted,
+		Flag:         flag,
+		CountLapFlag: countLapFlag,
+		Valid:        driver.LastLapTime > 0 && (countLapFlag == "" || countLapFlag == "COUNT_LAP_AND_TIME"),
+		CompletedAt:  m.now,
+	}
+	if driver.LastLapTime > 0 {
+		lap.LapTime = driver.LastLapTime
+		if driver.LastSectorTime1 > 0 && driver.LastSectorTime2 > driver.LastSectorTime1 {
+			lap.Sector1 = driver.LastSectorTime1
+			lap.Sector2 = driver.LastSectorTime2 - driver.LastSectorTime1
+			lap.Sector3 = driver.LastLapTime - driver.LastSectorTime2
+		}
+	}
+	return lap
+}
+
 func (m *Monitor) logDriverData(driver *models.StandingsData) {
 	if m.csvLogger == nil {
 		return
 	}
 
-	key := driver.DriverName
-	if stats, exists := m.driverStats[key]; exists {
+	if stats, exists := m.driverStats[driver.SlotID]; exists {
 		m.csvLogger.UpdateDriver(stats)
 	}
 }
 
-func (m *Monitor) updateDisplay() {
-	m.display.UpdateSession(m.session)
-	m.display.UpdateDrivers(m.drivers)
-	m.display.UpdateStats(m.driverStats)
-	m.display.Draw()
+func (m *Monitor) logLap(stats *models.DriverStats, lap models.LapRecord) {
+	if m.lapLogger == nil {
+		return
+	}
+	if err := m.lapLogger.LogLap(stats, lap); err != nil {
+		log.Printf("Error writing lap CSV: %v", err)
+	}
+}
+
+func (m *Monitor) publishSnapshot() {
+	if m.display == nil && m.api == nil {
+		return
+	}
+	snapshot := m.snapshot()
+	if m.display != nil {
+		m.display.Update(snapshot)
+	}
+	if m.api != nil {
+		m.api.Update(snapshot)
+	}
+}
+
+// snapshot copies the monitor state so it can be handed to other goroutines.
+// Lap histories are only ever appended to, so the copies share their backing
+// arrays with the capacity capped at the current length.
+func (m *Monitor) snapshot() *models.Snapshot {
+	snap := &models.Snapshot{
+		Time:       m.now,
+		Generation: m.generation,
+		Drivers:    make(map[int]*models.StandingsData, len(m.drivers)),
+		Stats:      make(map[int]*models.DriverStats, len(m.driverStats)),
+		IdealLaps:  make(map[string]*models.IdealLap, len(m.idealLaps)),
+	}
+
+	if m.session != nil {
+		session := *m.session
+		session.SectorFlag = append([]string(nil), m.session.SectorFlag...)
+		snap.Session = &session
+	}
+	for key, driver := range m.drivers {
+		driverCopy := *driver
+		snap.Drivers[key] = &driverCopy
+	}
+	for key, stats := range m.driverStats {
+		statsCopy := *stats
+		statsCopy.Laps = stats.Laps[:len(stats.Laps):len(stats.Laps)]
+		statsCopy.Drivers = slices.Clone(stats.Drivers)
+		statsCopy.Stints = slices.Clone(stats.Stints)
+		statsCopy.PitStops = slices.Clone(stats.PitStops)
+		statsCopy.PositionChanges = stats.PositionChanges[:len(stats.PositionChanges):len(stats.PositionChanges)]
+		snap.Stats[key] = &statsCopy
+	}
+	for class, ideal := range m.idealLaps {
+		idealCopy := *ideal
+		snap.IdealLaps[class] = &idealCopy
+	}
+	snap.Incidents = m.incidents[:len(m.incidents):len(m.incidents)]
+	snap.Events = m.events[:len(m.events):len(m.events)]
+	// Track maps are never modified once built.
+	snap.TrackMap = m.trackMap
+	return snap
 }
 
 func (m *Monitor) cleanup() {
 	log.Println("Shutting down...")
 
-	if m.csvLogger != nil {
-		if err := m.csvLogger.Close(); err != nil {
-			log.Printf("Error closing CSV logger: %v", err)
-		} else {
-			log.Println("CSV logging stopped")
+	m.closeSessionLoggers()
+
+	if m.api != nil {
+		if err := m.api.Close(); err != nil {
+			log.Printf("Error stopping API server: %v", err)
 		}
 	}
 
-	if m.conn != nil {
-		err := m.conn.Close()
-		if err != nil {
-			log.Printf("Error closing WebSocket connection: %v", err)
+	if m.recorder != nil {
+		if err := m.recorder.Close(); err != nil {
+			log.Printf("Error closing recording: %v", err)
+		} else {
+			log.Println("Recording stopped")
 		}
 	}
 }
@@ -0,0 +1,969 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/api"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/mockserver"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/recording"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/trackconfig"
+)
+
+var _ PlaybackSource = (*recording.Player)(nil)
+
+func frame(t *testing.T, msgType string, body interface{}) models.Frame {
+	t.Helper()
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("marshal %s: %v", msgType, err)
+	}
+	return models.Frame{Type: msgType, Body: raw, Received: time.Now()}
+}
+
+func newTestMonitor(t *testing.T, frames []models.Frame, setup ...func(*Monitor)) *Monitor {
+	t.Helper()
+	t.Chdir(t.TempDir())
+
+	ch := make(chan models.Frame, len(frames))
+	for _, f := range frames {
+		ch <- f
+	}
+	close(ch)
+
+	m := NewMonitorWithSource(NewChannelSource(ch))
+	m.display = nil
+	for _, fn := range setup {
+		fn(m)
+	}
+	m.consume()
+	m.cleanup()
+	return m
+}
+
+var testStart = time.Date(2025, 6, 14, 18, 0, 0, 0, time.UTC)
+
+// testCar is a car in a synthetic standings frame. The setters return a
+// copy, so one car can be varied from frame to frame.
+type testCar models.StandingsData
+
+func newTestCar(slotID int) testCar {
+	return testCar{
+		DriverName:   fmt.Sprintf("Driver %d", slotID),
+		CarClass:     "Hypercar",
+		SlotID:       slotID,
+		Flag:         "green",
+		FinishStatus: "FSTAT_NONE",
+		PitState:     "NONE",
+		CountLapFlag: "COUNT_LAP_AND_TIME",
+		TrackEdge:    6,
+	}
+}
+
+func (c testCar) class(class string) testCar          { c.CarClass = class; return c }
+func (c testCar) pos(position int) testCar            { c.Position = position; return c }
+func (c testCar) player() testCar                     { c.Player, c.HasFocus = true, true; return c }
+func (c testCar) lastLap(lapTime float64) testCar     { c.LastLapTime = lapTime; return c }
+func (c testCar) speed(kmh float64) testCar           { c.CarVelocity.Velocity = kmh / 3.6; return c }
+func (c testCar) lateral(lateral float64) testCar     { c.PathLateral = lateral; return c }
+func (c testCar) impact(acceleration float64) testCar { c.CarAcceleration.X = acceleration; return c }
+func (c testCar) fuel(fraction float64) testCar       { c.FuelFraction = fraction; return c }
+func (c testCar) pitstops(count int) testCar          { c.Pitstops = count; return c }
+func (c testCar) flag(flag string) testCar            { c.Flag = flag; return c }
+func (c testCar) penalties(count int) testCar         { c.Penalties = count; return c }
+func (c testCar) finish(status string) testCar        { c.FinishStatus = status; return c }
+func (c testCar) countLap(flag string) testCar        { c.CountLapFlag = flag; return c }
+func (c testCar) at(x float64, z float64) testCar     { c.CarPosition.X, c.CarPosition.Z = x, z; return c }
+func (c testCar) yellow() testCar                     { c.Flag, c.UnderYellow = "yellow", true; return c }
+
+func (c testCar) lap(completed int, distance float64, timeIntoLap float64) testCar {
+	c.LapsCompleted, c.LapDistance, c.TimeIntoLap = completed, distance, timeIntoLap
+	return c
+}
+
+func (c testCar) pit(state string) testCar {
+	c.PitState, c.Pitting = state, state != "NONE"
+	return c
+}
+
+// standingsFrame builds a standings frame received the given number of
+// seconds after testStart.
+func standingsFrame(t *testing.T, seconds float64, cars ...testCar) models.Frame {
+	t.Helper()
+	standings := make([]models.StandingsData, 0, len(cars))
+	for _, c := range cars {
+		standings = append(standings, models.StandingsData(c))
+	}
+	f := frame(t, "standings", standings)
+	f.Received = testStart.Add(time.Duration(seconds * float64(time.Second)))
+	return f
+}
+
+func TestUpdateDriverStatsTracksCalculatedBestLap(t *testing.T) {
+	session := models.SessionData{TrackName: "Circuit de la Sarthe", Session: "RACE1"}
+	lap := func(completed int, last float64, s1 float64, s2 float64, speed float64) []models.StandingsData {
+		return []models.StandingsData{{
+			DriverName:      "Driver One",
+			CarClass:        "Hypercar",
+			SlotID:          3,
+			Position:        1,
+			LapsCompleted:   completed,
+			LastLapTime:     last,
+			LastSectorTime1: s1,
+			LastSectorTime2: s2,
+			TimeIntoLap:     10,
+			CarVelocity:     models.CarVector{Velocity: speed},
+		}}
+	}
+
+	m := newTestMonitor(t, []models.Frame{
+		frame(t, "sessionInfo", session),
+		frame(t, "standings", lap(0, 0, 0, 0, 50)),
+		frame(t, "standings", lap(0, 0, 0, 0, 90)),
+		frame(t, "standings", lap(1, 210.5, 70.1, 140.3, 60)),
+		frame(t, "standings", lap(1, 210.5, 70.1, 140.3, 95)),
+		frame(t, "standings", lap(2, 212.0, 69.0, 141.0, 60)),
+	})
+
+	stats, ok := m.driverStats[3]
+	if !ok {
+		t.Fatalf("no stats recorded for driver")
+	}
+	if stats.BestLapTimeCalculated != 210.5 {
+		t.Errorf("BestLapTimeCalculated = %v, want 210.5", stats.BestLapTimeCalculated)
+	}
+	if stats.BestSector1Calculated != 70.1 {
+		t.Errorf("BestSector1Calculated = %v, want 70.1", stats.BestSector1Calculated)
+	}
+	lastLap, lastSector2 := 210.5, 140.3
+	if got, want := stats.BestSector3Calculated, lastLap-lastSector2; got != want {
+		t.Errorf("BestSector3Calculated = %v, want %v", got, want)
+	}
+	bestLapSpeed, topSpeed := 90.0, 95.0
+	if got, want := stats.MaxSpeedOnBestLapCalc, bestLapSpeed*3.6; got != want {
+		t.Errorf("MaxSpeedOnBestLapCalc = %v, want %v", got, want)
+	}
+	if got, want := stats.MaxSpeed, topSpeed*3.6; got != want {
+		t.Errorf("MaxSpeed = %v, want %v", got, want)
+	}
+
+	if len(stats.Laps) != 2 {
+		t.Fatalf("recorded %d laps, want 2", len(stats.Laps))
+	}
+	first, second := stats.Laps[0], stats.Laps[1]
+	if first.Lap != 1 || first.LapTime != 210.5 || first.Sector1 != 70.1 || first.MaxSpeed != bestLapSpeed*3.6 {
+		t.Errorf("unexpected first lap %+v", first)
+	}
+	if second.Lap != 2 || second.LapTime != 212.0 || second.MaxSpeed != topSpeed*3.6 || !second.Valid {
+		t.Errorf("unexpected second lap %+v", second)
+	}
+}
+
+func TestDriverSwapKeepsCarStats(t *testing.T) {
+	car := func(name string, steamID int64, completed int, last float64) []models.StandingsData {
+		return []models.StandingsData{
+			{DriverName: name, SteamID: steamID, SlotID: 7, VehicleFilename: "porsche_963", LapsCompleted: completed, LastLapTime: last},
+			{DriverName: name, SteamID: 99, SlotID: 8, VehicleFilename: "porsche_963_b", LapsCompleted: completed},
+		}
+	}
+
+	m := newTestMonitor(t, []models.Frame{
+		frame(t, "sessionInfo", models.SessionData{TrackName: "Spa", Session: "RACE1"}),
+		frame(t, "standings", car("Kevin Estre", 1, 10, 0)),
+		frame(t, "standings", car("Kevin Estre", 1, 11, 140.2)),
+		frame(t, "standings", car("Laurens Vanthoor", 2, 11, 140.2)),
+		frame(t, "standings", car("Laurens Vanthoor", 2, 12, 139.8)),
+	})
+
+	if len(m.driverStats) != 2 {
+		t.Fatalf("got stats for %d cars, want 2 despite identical names", len(m.driverStats))
+	}
+	stats := m.driverStats[7]
+	if stats.DriverName != "Laurens Vanthoor" || !slices.Equal(stats.Drivers, []string{"Kevin Estre", "Laurens Vanthoor"}) {
+		t.Errorf("driver history not tracked: current %q, drivers %v", stats.DriverName, stats.Drivers)
+	}
+	if stats.BestLapTimeCalculated != 139.8 || len(stats.Laps) != 2 {
+		t.Errorf("car stats lost across driver change: best %.3f, %d laps", stats.BestLapTimeCalculated, len(stats.Laps))
+	}
+	if stats.Laps[0].DriverName != "Kevin Estre" || stats.Laps[1].DriverName != "Laurens Vanthoor" {
+		t.Errorf("laps not attributed to their drivers: %+v", stats.Laps)
+	}
+
+	if len(stats.Stints) != 2 {
+		t.Fatalf("recorded %d stints, want 2", len(stats.Stints))
+	}
+	first, second := stats.Stints[0], stats.Stints[1]
+	if first.DriverName != "Kevin Estre" || !first.Finished || first.StartLap != 10 || first.EndLap != 11 || first.BestLap != 140.2 {
+		t.Errorf("unexpected first stint %+v", first)
+	}
+	if second.DriverName != "Laurens Vanthoor" || second.Finished || second.StartLap != 11 || second.EndLap != 12 || second.AverageLap != 139.8 {
+		t.Errorf("unexpected second stint %+v", second)
+	}
+}
+
+func TestSlotReuseWritesOpenStintAndPitStop(t *testing.T) {
+	leaving, joining := newTestCar(5).lap(8, 6700, 0), newTestCar(5).lap(0, 100, 0)
+	leaving.DriverName, leaving.VehicleFilename = "Leaving Driver", "porsche_963"
+	joining.DriverName, joining.VehicleFilename = "Joining Driver", "bmw_m4_gt3"
+
+	m := newTestMonitor(t, []models.Frame{
+		frame(t, "sessionInfo", models.SessionData{TrackName: "Spa", Session: "RACE1"}),
+		standingsFrame(t, 0, leaving.speed(250)),
+		standingsFrame(t, 1, leaving.pit("ENTERING").speed(80)),
+		standingsFrame(t, 5, leaving.pit("STOPPED")),
+		standingsFrame(t, 60, joining.speed(100)),
+	})
+
+	if stats := m.driverStats[5]; stats == nil || stats.DriverName != "Joining Driver" || len(stats.PitStops) != 0 {
+		t.Fatalf("slot not taken over by the new car: %+v", stats)
+	}
+	for suffix, want := range map[string][]string{
+		"stints":   {"Leaving Driver", "Joining Driver"},
+		"pitstops": {"Leaving Driver"},
+	} {
+		files, err := filepath.Glob("*_Spa_RACE1_" + suffix + ".csv")
+		if err != nil || len(files) != 1 {
+			t.Fatalf("expected one %s CSV, got %v (%v)", suffix, files, err)
+		}
+		data, err := os.ReadFile(files[0])
+		if err != nil {
+			t.Fatalf("read %s CSV: %v", suffix, err)
+		}
+		rows := strings.Split(strings.TrimSpace(string(data)), "\n")[1:]
+		if len(rows) != len(want) {
+			t.Fatalf("%s CSV rows %q, want one for each of %v", suffix, rows, want)
+		}
+		for i, driver := range want {
+			if !strings.Contains(rows[i], driver) {
+				t.Errorf("%s CSV row %q, want %s", suffix, rows[i], driver)
+			}
+		}
+		if suffix == "pitstops" && !strings.HasSuffix(rows[0], ";true") {
+			t.Errorf("pit stop of the leaving car not marked incomplete: %q", rows[0])
+		}
+	}
+
+	files, err := filepath.Glob("*_Spa_RACE1_telemetry.csv")
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one summary CSV, got %v (%v)", files, err)
+	}
+	summary, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("read summary CSV: %v", err)
+	}
+	if rows := strings.Count(string(summary), "\n") - 1; rows != 2 || !strings.Contains(string(summary), "Leaving Driver") || !strings.Contains(string(summary), "Joining Driver") {
+		t.Errorf("summary CSV does not list both cars:\n%s", summary)
+	}
+}
+
+func TestPitStopTiming(t *testing.T) {
+	car, late := newTestCar(1).lap(12, 6800, 0).fuel(0.08), newTestCar(2).lap(12, 6700, 0)
+	car.PitLapDistance = 6750
+
+	m := newTestMonitor(t, []models.Frame{
+		frame(t, "sessionInfo", models.SessionData{TrackName: "Spa", Session: "RACE1"}),
+		standingsFrame(t, 0, car.speed(250), late.speed(250)),
+		standingsFrame(t, 1, car.pit("ENTERING").speed(80), late.speed(250)),
+		standingsFrame(t, 6, car.pit("STOPPED"), late.speed(250)),
+		standingsFrame(t, 20, car.pit("STOPPED").fuel(0.6), late.speed(250)),
+		standingsFrame(t, 27, car.pit("EXITING").speed(55).fuel(1).pitstops(1), late.speed(250)),
+		standingsFrame(t, 36, car.pit("NONE").speed(150).fuel(1).pitstops(1), late.pit("ENTERING").speed(80)),
+		standingsFrame(t, 40, car.pit("NONE").speed(150).fuel(1).pitstops(1), late.pit("STOPPED")),
+	})
+
+	stops := m.driverStats[1].PitStops
+	if len(stops) != 1 {
+		t.Fatalf("recorded %d pit stops, want 1", len(stops))
+	}
+	stop := stops[0]
+	if stop.PitLaneTime != 35 || stop.StationaryTime != 21 {
+		t.Errorf("pit lane %.1fs stationary %.1fs, want 35s and 21s", stop.PitLaneTime, stop.StationaryTime)
+	}
+	if !stop.Serviced || stop.Incomplete || stop.FuelBefore != 0.08 || stop.FuelAfter != 1.0 || stop.Lap != 12 || stop.EntryLapDistance != 6750 {
+		t.Errorf("unexpected pit stop %+v", stop)
+	}
+
+	stops = m.driverStats[2].PitStops
+	if len(stops) != 1 || !stops[0].Incomplete || stops[0].PitLaneTime != 4 {
+		t.Fatalf("open pit stop not finished at the end of the session: %+v", stops)
+	}
+	files, err := filepath.Glob("*_Spa_RACE1_pitstops.csv")
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one pit stop CSV, got %v (%v)", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("read pit stop CSV: %v", err)
+	}
+	if rows := strings.Count(string(data), "\n"); rows != 3 {
+		t.Errorf("pit stop CSV has %d lines, want header and 2 rows", rows)
+	}
+}
+
+func TestIdealSectorsAcrossLapsAndCars(t *testing.T) {
+	car := func(slot int, name string, completed int, s1 float64, s2 float64, last float64, countLapFlag string) models.StandingsData {
+		return models.StandingsData{
+			DriverName:      name,
+			CarClass:        "Hypercar",
+			SlotID:          slot,
+			LapsCompleted:   completed,
+			LastSectorTime1: s1,
+			LastSectorTime2: s2,
+			LastLapTime:     last,
+			CountLapFlag:    countLapFlag,
+		}
+	}
+	valid := "COUNT_LAP_AND_TIME"
+
+	m := newTestMonitor(t, []models.Frame{
+		frame(t, "sessionInfo", models.SessionData{TrackName: "Spa", Session: "RACE1"}),
+		frame(t, "standings", []models.StandingsData{car(1, "Driver One", 0, 0, 0, 0, valid), car(2, "Driver Two", 0, 0, 0, 0, valid)}),
+		frame(t, "standings", []models.StandingsData{car(1, "Driver One", 1, 30, 70, 110, valid), car(2, "Driver Two", 1, 29.5, 72, 112, valid)}),
+		frame(t, "standings", []models.StandingsData{car(1, "Driver One", 2, 31, 70, 108, "COUNT_LAP"), car(2, "Driver Two", 1, 29.5, 72, 112, valid)}),
+		frame(t, "standings", []models.StandingsData{car(1, "Driver One", 3, 20, 50, 80, "COUNT_LAP"), car(2, "Driver Two", 1, 29.5, 72, 112, valid)}),
+	})
+
+	stats := m.driverStats[1]
+	if stats.IdealSector1 != 30 || stats.IdealSector2 != 39 || stats.IdealSector3 != 38 || stats.TheoreticalBestLap != 107 {
+		t.Errorf("ideal sectors %.3f/%.3f/%.3f = %.3f, want 30/39/38 = 107 without the invalid lap",
+			stats.IdealSector1, stats.IdealSector2, stats.IdealSector3, stats.TheoreticalBestLap)
+	}
+
+	ideal := m.idealLaps["Hypercar"]
+	if ideal == nil {
+		t.Fatalf("no ideal lap for class")
+	}
+	if ideal.Sector1.SlotID != 2 || ideal.Sector2.SlotID != 1 || ideal.Sector3.SlotID != 1 || ideal.LapTime() != 106.5 {
+		t.Errorf("unexpected class ideal lap %+v", ideal)
+	}
+}
+
+func TestLiveDeltaAgainstBestLap(t *testing.T) {
+	car, other := newTestCar(1).player().lastLap(100), newTestCar(2).lastLap(100)
+
+	m := newTestMonitor(t, []models.Frame{
+		frame(t, "sessionInfo", models.SessionData{TrackName: "Spa", Session: "PRACTICE1", LapDistance: 1000}),
+		standingsFrame(t, 0, car.lap(0, 800, 80), other.lap(0, 800, 80)),
+		standingsFrame(t, 1, car.lap(1, 10, 1), other.lap(1, 10, 1)),
+		standingsFrame(t, 2, car.lap(1, 500, 50), other.lap(1, 500, 50)),
+		standingsFrame(t, 3, car.lap(1, 900, 90), other.lap(1, 900, 90)),
+		standingsFrame(t, 4, car.lap(2, 10, 1), other.lap(2, 10, 1)),
+		standingsFrame(t, 5, car.lap(2, 500, 49), other.lap(2, 500, 49)),
+	})
+
+	delta := m.driverStats[1].Delta
+	if !delta.Active || delta.ReferenceLap != 100 || delta.Delta != -1 {
+		t.Errorf("unexpected delta %+v, want -1s against a 100s reference", delta)
+	}
+	if delta := m.driverStats[2].Delta; delta.Active || delta.ReferenceLap != 0 {
+		t.Errorf("delta %+v tracked for a car that is not the player's", delta)
+	}
+}
+
+func TestLiveDeltaFromZeroDistanceReference(t *testing.T) {
+	car := newTestCar(1).player().lastLap(100)
+
+	m := newTestMonitor(t, []models.Frame{
+		frame(t, "sessionInfo", models.SessionData{TrackName: "Spa", Session: "PRACTICE1", LapDistance: 1000}),
+		standingsFrame(t, 0, car.lap(0, 800, 80)),
+		standingsFrame(t, 1, car.lap(1, 0, 0)),
+		standingsFrame(t, 2, car.lap(1, 500, 50)),
+		standingsFrame(t, 3, car.lap(2, 0, 0)),
+	})
+
+	delta := m.driverStats[1].Delta
+	if !delta.Active || delta.Delta != 0 {
+		t.Errorf("unexpected delta %+v on the line, want 0", delta)
+	}
+}
+
+func TestSpeedTrapsAndMiniSectors(t *testing.T) {
+	car := newTestCar(1).lastLap(100)
+	config := trackconfig.Config{"Spa": {
+		SpeedTraps: []trackconfig.Range{
+			{Name: "Line", Start: 0, End: 60},
+			{Name: "Kemmel", Start: 400, End: 450},
+		},
+		MiniSectors: []trackconfig.Range{
+			{Name: "La Source", Start: 0, End: 100},
+			{Name: "Eau Rouge", Start: 100, End: 300},
+			{Name: "Final", Start: 800, End: 1000},
+		},
+	}}
+
+	m := newTestMonitor(t, []models.Frame{
+		frame(t, "sessionInfo", models.SessionData{TrackName: "Spa", Session: "PRACTICE1", LapDistance: 1000}),
+		standingsFrame(t, 0, car.lap(0, 50, 5).speed(180)),
+		standingsFrame(t, 1, car.lap(0, 200, 20).speed(180)),
+		standingsFrame(t, 2, car.lap(0, 350, 35).speed(216)),
+		standingsFrame(t, 3, car.lap(0, 425, 42.5).speed(288)),
+		standingsFrame(t, 4, car.lap(0, 600, 60).speed(252)),
+		standingsFrame(t, 5, car.lap(0, 900, 90).speed(216)),
+		standingsFrame(t, 6, car.lap(1, 10, 1).speed(216)),
+	}, func(m *Monitor) { m.trackConfig = config })
+
+	laps := m.driverStats[1].Laps
+	if len(laps) != 1 {
+		t.Fatalf("recorded %d laps, want 1", len(laps))
+	}
+	want := []models.LapSplit{
+		{Kind: models.SplitSpeedTrap, Name: "Line", Value: 180},
+		{Kind: models.SplitSpeedTrap, Name: "Kemmel", Value: 288},
+		{Kind: models.SplitMiniSector, Name: "La Source", Value: 10},
+		{Kind: models.SplitMiniSector, Name: "Eau Rouge", Value: 20},
+		{Kind: models.SplitMiniSector, Name: "Final", Value: 20},
+	}
+	splits := laps[0].Splits
+	if len(splits) != len(want) {
+		t.Fatalf("got splits %+v, want %+v", splits, want)
+	}
+	for i := range want {
+		if splits[i].Kind != want[i].Kind || splits[i].Name != want[i].Name || math.Abs(splits[i].Value-want[i].Value) > 1e-9 {
+			t.Errorf("split %d = %+v, want %+v", i, splits[i], want[i])
+		}
+	}
+
+	files, err := filepath.Glob("*_Spa_PRACTICE1_splits.csv")
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one splits CSV, got %v (%v)", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("read splits CSV: %v", err)
+	}
+	if rows := strings.Count(string(data), "\n"); rows != 6 {
+		t.Errorf("splits CSV has %d lines, want header and 5 rows", rows)
+	}
+}
+
+func TestIncidentDetection(t *testing.T) {
+	car := newTestCar(1).lap(4, 1200, 60)
+
+	m := newTestMonitor(t, []models.Frame{
+		frame(t, "sessionInfo", models.SessionData{TrackName: "Spa", Session: "RACE1"}),
+		standingsFrame(t, 0, car.speed(200)),
+		standingsFrame(t, 0.5, car.speed(190).lateral(8)),
+		standingsFrame(t, 1, car.speed(190).lateral(9)),
+		standingsFrame(t, 1.5, car.speed(10).lateral(2)),
+		standingsFrame(t, 2, car.speed(50).impact(70)),
+		standingsFrame(t, 3, car.speed(60).impact(75)),
+	})
+
+	kinds := make([]string, 0, len(m.incidents))
+	for _, incident := range m.incidents {
+		kinds = append(kinds, incident.Kind)
+	}
+	want := []string{models.IncidentOffTrack, models.IncidentSpin, models.IncidentContact}
+	if !slices.Equal(kinds, want) {
+		t.Fatalf("detected %v, want %v", kinds, want)
+	}
+	if incident := m.incidents[0]; incident.Lap != 5 || incident.LapDistance != 1200 {
+		t.Errorf("unexpected incident %+v", incident)
+	}
+	if m.driverStats[1].Incidents != 3 {
+		t.Errorf("car has %d incidents, want 3", m.driverStats[1].Incidents)
+	}
+}
+
+func TestSpinDetection(t *testing.T) {
+	spinning, braking, queueing := newTestCar(1).lap(4, 1200, 60), newTestCar(2).lap(4, 2200, 60), newTestCar(3).lap(4, 1100, 60).yellow()
+
+	// The spinning and queueing cars slow down at 20 m/s², like a car sliding
+	// to a stop; the braking car brakes harder but only down to hairpin speed.
+	var frames []models.Frame
+	frames = append(frames, frame(t, "sessionInfo", models.SessionData{TrackName: "Spa", Session: "RACE1"}))
+	slowing := []float64{160, 124, 88, 52, 16, 0, 0}
+	hairpin := []float64{250, 180, 110, 70, 110, 150, 190}
+	for i := range slowing {
+		frames = append(frames, standingsFrame(t, float64(i)/2,
+			spinning.speed(slowing[i]), braking.speed(hairpin[i]), queueing.speed(slowing[i])))
+	}
+	m := newTestMonitor(t, frames)
+
+	if len(m.incidents) != 1 {
+		t.Fatalf("detected %+v, want one spin", m.incidents)
+	}
+	if incident := m.incidents[0]; incident.SlotID != 1 || incident.Kind != models.IncidentSpin || incident.Detail != "88 to 16 km/h in 1.0s" {
+		t.Errorf("unexpected incident %+v", incident)
+	}
+}
+
+func TestRaceControlEvents(t *testing.T) {
+	session := func(phase int, yellowState string, sectorFlag ...string) models.Frame {
+		return frame(t, "sessionInfo", models.SessionData{
+			TrackName:       "Spa",
+			Session:         "RACE1",
+			GamePhase:       phase,
+			YellowFlagState: yellowState,
+			SectorFlag:      sectorFlag,
+		})
+	}
+	one, two, three, four := newTestCar(1), newTestCar(2), newTestCar(3).class("GT3"), newTestCar(4).class("GT3")
+
+	m := newTestMonitor(t, []models.Frame{
+		session(4, "NONE", "GREEN", "GREEN", "GREEN"),
+		standingsFrame(t, 0, one.pos(1), two.pos(2), three.pos(3), four.pos(4)),
+		session(5, "NONE", "GREEN", "YELLOW", "GREEN"),
+		standingsFrame(t, 1, two.pos(1), one.pos(2).penalties(1), four.pos(3), three.pos(4).flag("blue")),
+		session(6, "PITS_CLOSED", "GREEN", "GREEN", "GREEN"),
+		standingsFrame(t, 2, two.pos(1), one.pos(2).penalties(1), four.pos(3), three.pos(4).finish("FSTAT_DNF")),
+		session(5, "NONE", "GREEN", "GREEN", "GREEN"),
+	})
+
+	var messages []string
+	for _, event := range m.events {
+		messages = append(messages, fmt.Sprintf("%d %s", event.SlotID, event.Message))
+	}
+	want := []string{
+		"-1 Green flag",
+		"-1 Yellow flag in sector 2",
+		"1 Penalty, 1 outstanding",
+		"3 Blue flag",
+		"2 Takes the lead",
+		"4 Takes the GT3 lead",
+		"-1 Full course yellow, pits closed",
+		"-1 Sector 2 clear",
+		"3 Retired",
+		"-1 Green flag",
+		"-1 Full course yellow over",
+	}
+	if !slices.Equal(messages, want) {
+		t.Fatalf("events:\n%s\nwant:\n%s", strings.Join(messages, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLeadChangeWhenLeaderLeaves(t *testing.T) {
+	one, two, three := newTestCar(1), newTestCar(2), newTestCar(3).class("GT3")
+
+	m := newTestMonitor(t, []models.Frame{
+		frame(t, "sessionInfo", models.SessionData{TrackName: "Spa", Session: "RACE1"}),
+		standingsFrame(t, 0, one.pos(1), two.pos(2), three.pos(3)),
+		standingsFrame(t, 1, two.pos(1), three.pos(2)),
+		standingsFrame(t, 2, two.pos(1), three.pos(2)),
+		standingsFrame(t, 3, two.pos(1), three.pos(2)),
+	})
+
+	var messages []string
+	for _, event := range m.events {
+		messages = append(messages, fmt.Sprintf("%d %s", event.SlotID, event.Message))
+	}
+	if want := []string{"2 Takes the lead"}; !slices.Equal(messages, want) {
+		t.Errorf("events %q, want %q", messages, want)
+	}
+}
+
+func TestPositionChangesAndOvertakes(t *testing.T) {
+	one, two, three := newTestCar(1).lap(3, 0, 0), newTestCar(2).lap(3, 0, 0), newTestCar(3).lap(3, 0, 0)
+
+	m := newTestMonitor(t, []models.Frame{
+		frame(t, "sessionInfo", models.SessionData{TrackName: "Spa", Session: "RACE1"}),
+		standingsFrame(t, 0, one.pos(1), two.pos(2), three.pos(3)),
+		standingsFrame(t, 1, one.pos(2), two.pos(1), three.pos(3)),
+		standingsFrame(t, 2, one.pos(3).pit("ENTERING"), two.pos(1), three.pos(2)),
+	})
+
+	first, second, third := m.driverStats[1], m.driverStats[2], m.driverStats[3]
+	if second.Overtakes != 1 || first.TimesOvertaken != 1 || third.Overtakes != 0 || first.Overtakes != 0 {
+		t.Errorf("overtakes: 1=%d/%d 2=%d/%d 3=%d/%d",
+			first.Overtakes, first.TimesOvertaken, second.Overtakes, second.TimesOvertaken, third.Overtakes, third.TimesOvertaken)
+	}
+
+	var kinds []string
+	for _, change := range first.PositionChanges {
+		kinds = append(kinds, fmt.Sprintf("%d>%d %s %v", change.From, change.To, change.Kind, change.Cars))
+	}
+	want := []string{"1>2 on-track [---]", "2>3 pit-cycle [---]"}
+	if !slices.Equal(kinds, want) {
+		t.Errorf("position changes %v, want %v", kinds, want)
+	}
+
+	if first.StartPosition != 1 || first.PositionsGained() != -2 || third.PositionsGained() != 1 {
+		t.Errorf("start %d, gained %d and %d", first.StartPosition, first.PositionsGained(), third.PositionsGained())
+	}
+}
+
+func TestTrackMapSamplesCleanRunningOnly(t *testing.T) {
+	car := newTestCar(1).lap(2, 5, 1)
+
+	m := newTestMonitor(t, []models.Frame{
+		frame(t, "sessionInfo", models.SessionData{TrackName: "Spa", Session: "RACE1", LapDistance: 1000}),
+		standingsFrame(t, 0, car.at(100, 0)),
+		standingsFrame(t, 1, car.at(500, 0).lateral(7)),
+		standingsFrame(t, 2, car.at(700, 0).pit("ENTERING")),
+		standingsFrame(t, 3, car.at(900, 0).countLap("COUNT_LAP")),
+		standingsFrame(t, 4, car.at(110, 0)),
+	})
+
+	if m.trackMapBuilder == nil {
+		t.Fatalf("no track map being built")
+	}
+	points := m.trackMapBuilder.Build().Points
+	if len(points) != 1 || points[0].X != 105 {
+		t.Errorf("outline points %+v, want the two clean samples averaged", points)
+	}
+}
+
+func TestStartPositionFromGridWhenJoiningMidRace(t *testing.T) {
+	one, two, three := newTestCar(1).lap(10, 0, 0), newTestCar(2).lap(10, 0, 0), newTestCar(3).lap(10, 0, 0)
+	one.Qualification, two.Qualification = 3, 1
+
+	m := newTestMonitor(t, []models.Frame{
+		frame(t, "sessionInfo", models.SessionData{TrackName: "Spa", Session: "RACE1"}),
+		standingsFrame(t, 0, one.pos(1), two.pos(2), three.pos(3)),
+		standingsFrame(t, 1, one.pos(1), two.pos(3), three.pos(2)),
+	})
+
+	first, second, third := m.driverStats[1], m.driverStats[2], m.driverStats[3]
+	if first.StartPosition != 3 || first.PositionsGained() != 2 || second.StartPosition != 1 || second.PositionsGained() != -2 {
+		t.Errorf("grid start positions not used: 1 from %d gained %d, 2 from %d gained %d",
+			first.StartPosition, first.PositionsGained(), second.StartPosition, second.PositionsGained())
+	}
+	if third.StartPosition != 3 || third.PositionsGained() != 1 {
+		t.Errorf("car without a grid slot: from %d gained %d, want from 3 gained 1", third.StartPosition, third.PositionsGained())
+	}
+}
+
+func TestResetFrameClearsSession(t *testing.T) {
+	m := newTestMonitor(t, []models.Frame{
+		frame(t, "sessionInfo", models.SessionData{TrackName: "Monza", Session: "PRACTICE1"}),
+		frame(t, "standings", []models.StandingsData{{DriverName: "Driver One", SlotID: 1}}),
+		{Type: recording.ResetFrameType, Received: time.Now()},
+	})
+
+	if m.session != nil || len(m.drivers) != 0 || len(m.driverStats) != 0 {
+		t.Fatalf("state not reset: session=%v drivers=%d stats=%d", m.session, len(m.drivers), len(m.driverStats))
+	}
+	if generation := m.snapshot().Generation; generation != 2 {
+		t.Errorf("snapshot generation %d, want 2 after the session start and the reset", generation)
+	}
+}
+
+func TestMonitorAgainstMockServer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("end-to-end test skipped in short mode")
+	}
+	t.Chdir(t.TempDir())
+
+	server := mockserver.NewServer(6, 1)
+	server.SetTick(10 * time.Millisecond)
+	server.SetTimeScale(200)
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+	go server.Run()
+	defer server.Stop()
+
+	addr, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("parse server URL: %v", err)
+	}
+
+	m := NewMonitor(addr.Hostname(), addr.Port(), addr.Port())
+	m.display = nil
+	// The API is only used to watch the monitor's progress from this goroutine.
+	m.api = api.NewServer()
+
+	done := make(chan struct{})
+	go func() {
+		m.consume()
+		close(done)
+	}()
+
+	deadline := time.Now().Add(60 * time.Second)
+	for !mockRaceProgressed(t, m.api.Handler()) && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	m.source.Close()
+	<-done
+	m.cleanup()
+
+	if m.session == nil || m.session.TrackName != "Mock Raceway" {
+		t.Fatalf("session not received: %+v", m.session)
+	}
+	if len(m.driverStats) != 6 {
+		t.Fatalf("got stats for %d drivers, want 6", len(m.driverStats))
+	}
+	for slot, stats := range m.driverStats {
+		if stats.LapsCompleted < 2 {
+			t.Errorf("slot %d completed %d laps, want at least 2", slot, stats.LapsCompleted)
+		}
+		if stats.BestLapTimeCalculated < 80 || stats.BestLapTimeCalculated > 130 {
+			t.Errorf("slot %d best lap %.3f is not a believable lap time", slot, stats.BestLapTimeCalculated)
+		}
+		if stats.VehicleNumber == "---" || strings.Contains(stats.VehicleModel, "#") {
+			t.Errorf("slot %d vehicle info not loaded from REST: model %q number %q", slot, stats.VehicleModel, stats.VehicleNumber)
+		}
+	}
+
+	files, err := filepath.Glob("*_Mock_Raceway_RACE1_telemetry.csv")
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one CSV file, got %v (%v)", files, err)
+	}
+
+	lapFiles, err := filepath.Glob("*_Mock_Raceway_RACE1_laps.csv")
+	if err != nil || len(lapFiles) != 1 {
+		t.Fatalf("expected one lap CSV file, got %v (%v)", lapFiles, err)
+	}
+	content, err := os.ReadFile(lapFiles[0])
+	if err != nil {
+		t.Fatalf("read lap CSV: %v", err)
+	}
+	laps := 0
+	for _, stats := range m.driverStats {
+		laps += len(stats.Laps)
+	}
+	if rows := strings.Count(string(content), "\n") - 1; rows != laps {
+		t.Errorf("lap CSV has %d rows, want %d", rows, laps)
+	}
+
+	var fcy []string
+	for _, event := range m.events {
+		if event.Kind == models.EventFullCourseYellow {
+			fcy = append(fcy, event.Message)
+		}
+	}
+	if len(fcy) < 2 || fcy[0] != "Full course yellow" || fcy[len(fcy)-1] != "Full course yellow over" {
+		t.Errorf("full course yellow events = %q, want deployment through to the end", fcy)
+	}
+}
+
+// mockRaceProgressed reports whether every mock car has set a lap time and
+// the full course yellow is over.
+func mockRaceProgressed(t *testing.T, handler http.Handler) bool {
+	t.Helper()
+	var standings []api.Standing
+	var events []models.RaceEvent
+	if get(t, handler, "/api/standings", &standings) != http.StatusOK || get(t, handler, "/api/events", &events) != http.StatusOK {
+		return false
+	}
+	if len(standings) < 6 {
+		return false
+	}
+	for _, standing := range standings {
+		if standing.Stats == nil || standing.Stats.LapsCompleted < 2 || standing.Stats.BestLapTimeCalculated <= 0 {
+			return false
+		}
+	}
+	for _, event := range events {
+		if event.Message == "Full course yellow over" {
+			return true
+		}
+	}
+	return false
+}
+
+func get(t *testing.T, handler http.Handler, path string, body interface{}) int {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	if recorder.Code == http.StatusOK {
+		if err := json.Unmarshal(recorder.Body.Bytes(), body); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+	}
+	return recorder.Code
+}
+
+func writeSyntheticRace(t *testing.T, filename string, cars int, laps int) {
+	t.Helper()
+	recorder, err := recording.NewRecorder(filename)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	defer recorder.Close()
+
+	const stepsPerLap = 20
+	start := time.Date(2025, 6, 14, 16, 0, 0, 0, time.UTC)
+	session := models.SessionData{TrackName: "Circuit de la Sarthe", Session: "RACE1", EndEventTime: 86400}
+
+	for step := 0; step <= laps*stepsPerLap; step++ {
+		received := start.Add(time.Duration(step) * 100 * time.Millisecond)
+		if step%10 == 0 {
+			session.CurrentEventTime = float64(step) / 10
+			f := frame(t, "sessionInfo", session)
+			f.Received = received
+			if err := recorder.Record(f); err != nil {
+				t.Fatalf("Record: %v", err)
+			}
+		}
+
+		standings := make([]models.StandingsData, 0, cars)
+		for car := 0; car < cars; car++ {
+			lapTime := 200 + float64(car)
+			completed := step / stepsPerLap
+			last := 0.0
+			if completed > 0 {
+				last = lapTime + float64(completed%3)
+			}
+			standings = append(standings, models.StandingsData{
+				DriverName:      fmt.Sprintf("Driver %d", car),
+				CarClass:        "Hypercar",
+				SlotID:          car,
+				Position:        car + 1,
+				LapsCompleted:   completed,
+				LastLapTime:     last,
+				LastSectorTime1: last / 3,
+				LastSectorTime2: 2 * last / 3,
+				TimeIntoLap:     float64(step%stepsPerLap) * lapTime / stepsPerLap,
+				LapDistance:     float64(step%stepsPerLap) * 13626 / stepsPerLap,
+				CarVelocity:     models.CarVector{Velocity: 60 + float64(step%stepsPerLap)},
+			})
+		}
+		f := frame(t, "standings", standings)
+		f.Received = received
+		if err := recorder.Record(f); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+}
+
+func TestReplayDrivesDisplayWithoutRaces(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeSyntheticRace(t, "race.lmurec.gz", 8, 6)
+
+	player, err := recording.NewPlayer("race.lmurec.gz", 0)
+	if err != nil {
+		t.Fatalf("NewPlayer: %v", err)
+	}
+	m := NewMonitorWithSource(player)
+
+	screen := tcell.NewSimulationScreen("UTF-8")
+	m.display.SetScreen(screen)
+	screen.SetSize(200, 60)
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- m.Run()
+	}()
+
+	keysDone := make(chan struct{})
+	go func() {
+		defer close(keysDone)
+		for _, key := range "fsfs" {
+			screen.InjectKey(tcell.KeyRune, key, tcell.ModNone)
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+
+	select {
+	case <-m.consumeDone:
+	case <-time.After(10 * time.Second):
+		t.Fatal("replay did not finish")
+	}
+	<-keysDone
+	m.display.Stop()
+
+	if err := <-runErr; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(m.driverStats) != 8 {
+		t.Fatalf("got stats for %d drivers, want 8", len(m.driverStats))
+	}
+	for slot, stats := range m.driverStats {
+		if len(stats.Laps) != 6 {
+			t.Errorf("slot %d has %d laps, want 6", slot, len(stats.Laps))
+		}
+	}
+}
+
+func TestHeadlessReplayStopsAtEndOfRecording(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeSyntheticRace(t, "race.lmurec.gz", 4, 3)
+
+	player, err := recording.NewPlayer("race.lmurec.gz", 0)
+	if err != nil {
+		t.Fatalf("NewPlayer: %v", err)
+	}
+	m := NewMonitorWithSource(player)
+	m.EnableHeadless()
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- m.Run()
+	}()
+
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("headless replay did not finish")
+	}
+
+	if m.csvLogger != nil || m.lapLogger != nil {
+		t.Error("session loggers left open")
+	}
+	laps, err := filepath.Glob("*_laps.csv")
+	if err != nil || len(laps) != 1 {
+		t.Fatalf("lap CSVs %v: %v", laps, err)
+	}
+	content, err := os.ReadFile(laps[0])
+	if err != nil {
+		t.Fatalf("read lap CSV: %v", err)
+	}
+	if rows := strings.Count(string(content), "\n") - 1; rows != 12 {
+		t.Errorf("lap CSV has %d rows, want 12", rows)
+	}
+}
+
+// rewindingSource seeks a replay back to the start once it has played the
+// given number of frames.
+type rewindingSource struct {
+	*recording.Player
+	after  int
+	played int
+}
+
+func (s *rewindingSource) Next() (models.Frame, error) {
+	s.played++
+	if s.played == s.after {
+		s.Seek(-time.Hour)
+	}
+	return s.Player.Next()
+}
+
+func TestReplayRewindDoesNotDuplicateRows(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeSyntheticRace(t, "race.lmurec.gz", 4, 3)
+
+	player, err := recording.NewPlayer("race.lmurec.gz", 0)
+	if err != nil {
+		t.Fatalf("NewPlayer: %v", err)
+	}
+	m := NewMonitorWithSource(&rewindingSource{Player: player, after: 50})
+	m.display = nil
+	m.consume()
+	m.cleanup()
+
+	laps, err := filepath.Glob("*_laps.csv")
+	if err != nil || len(laps) != 1 {
+		t.Fatalf("lap CSVs %v: %v", laps, err)
+	}
+	content, err := os.ReadFile(laps[0])
+	if err != nil {
+		t.Fatalf("read lap CSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	if rows := len(lines) - 1; rows != 12 {
+		t.Errorf("lap CSV has %d rows, want 12", rows)
+	}
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		if seen[line] {
+			t.Errorf("duplicate row %q", line)
+		}
+		seen[line] = true
+	}
+}
@@ -0,0 +1,117 @@
+package telemetry
+
+import (
+	"log"
+	"time"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+// Below this speed (m/s) a car in the pit lane counts as stationary.
+const stationarySpeed = 0.5
+
+type pitTracker struct {
+	seenOnTrack     bool
+	stop            *models.PitStop
+	pitstopsAtEntry int
+	stationarySince time.Time
+}
+
+func inPitLane(driver *models.StandingsData) bool {
+	if driver.Pitting || driver.InGarageStall {
+		return true
+	}
+	switch driver.PitState {
+	case "ENTERING", "STOPPED", "EXITING":
+		return true
+	}
+	return false
+}
+
+func (m *Monitor) updatePitStops(stats *models.DriverStats, driver *models.StandingsData, tracker *pitTracker) {
+	if !inPitLane(driver) {
+		if tracker.stop != nil {
+			m.finishPitStop(stats, driver, tracker)
+		}
+		tracker.seenOnTrack = true
+		return
+	}
+
+	// Cars sitting in the garage when we connect have not made a stop yet.
+	if !tracker.seenOnTrack {
+		return
+	}
+
+	if tracker.stop == nil {
+		// The game reports where the car entered the pit lane; the first
+		// sample in the pit lane can be some way past it.
+		entry := driver.PitLapDistance
+		if entry <= 0 {
+			entry = driver.LapDistance
+		}
+		tracker.stop = &models.PitStop{
+			Number:           len(stats.PitStops) + 1,
+			Lap:              driver.LapsCompleted,
+			DriverName:       driver.DriverName,
+			EntryLapDistance: entry,
+			EntryAt:          m.now,
+			FuelBefore:       driver.FuelFraction,
+		}
+		tracker.pitstopsAtEntry = driver.Pitstops
+		tracker.stationarySince = time.Time{}
+	}
+
+	stop := tracker.stop
+	if driver.InGarageStall {
+		stop.InGarage = true
+	}
+	stationary := driver.PitState == "STOPPED" || driver.CarVelocity.Velocity < stationarySpeed
+	if stationary && tracker.stationarySince.IsZero() {
+		tracker.stationarySince = m.now
+	} else if !stationary && !tracker.stationarySince.IsZero() {
+		stop.StationaryTime += m.now.Sub(tracker.stationarySince).Seconds()
+		tracker.stationarySince = time.Time{}
+	}
+	stop.FuelAfter = driver.FuelFraction
+}
+
+func (m *Monitor) finishPitStop(stats *models.DriverStats, driver *models.StandingsData, tracker *pitTracker) {
+	stop := tracker.stop
+	tracker.stop = nil
+
+	if !tracker.stationarySince.IsZero() {
+		stop.StationaryTime += m.now.Sub(tracker.stationarySince).Seconds()
+		tracker.stationarySince = time.Time{}
+	}
+	stop.ExitAt = m.now
+	stop.PitLaneTime = stop.ExitAt.Sub(stop.EntryAt).Seconds()
+	stop.FuelAfter = driver.FuelFraction
+	stop.Serviced = driver.Pitstops > tracker.pitstopsAtEntry
+
+	stats.PitStops = append(stats.PitStops, *stop)
+	incomplete := ""
+	if stop.Incomplete {
+		incomplete = " (incomplete)"
+	}
+	log.Printf("Pit stop %d for car #%s (%s) on lap %d: %.1fs in pit lane, %.1fs stationary%s",
+		stop.Number, stats.VehicleNumber, stop.DriverName, stop.Lap, stop.PitLaneTime, stop.StationaryTime, incomplete)
+
+	if m.pitStopLogger != nil {
+		if err := m.pitStopLogger.LogPitStop(stats, *stop); err != nil {
+			log.Printf("Error writing pit stop CSV: %v", err)
+		}
+	}
+}
+
+// finishOpenPitStops ends the stops of cars still in the pit lane, marked as
+// incomplete, so they are not lost when the session ends.
+func (m *Monitor) finishOpenPitStops() {
+	for key, lapState := range m.lapStates {
+		stats, driver := m.driverStats[key], m.drivers[key]
+		if lapState.pit.stop == nil || stats == nil || driver == nil {
+			continue
+		}
+		lapState.pit.stop.Incomplete = true
+		m.finishPitStop(stats, driver, &lapState.pit)
+	}
+}
@@ -0,0 +1,57 @@
+package telemetry
+
+import (
+	"fmt"
+	"time"
+)
+
+var playbackSpeeds = []float64{1, 2, 5, 10, 0}
+
+func (m *Monitor) bindPlaybackKeys(playback PlaybackSource) {
+	changeSpeed := func(step int) {
+		current := 0
+		for i, speed := range playbackSpeeds {
+			if speed == playback.Speed() {
+				current = i
+			}
+		}
+		next := current + step
+		if next < 0 || next >= len(playbackSpeeds) {
+			return
+		}
+		playback.SetSpeed(playbackSpeeds[next])
+	}
+
+	m.display.BindKey(' ', "Space - pause", playback.TogglePause)
+	m.display.BindKey(',', ", . - seek 30s", func() { playback.Seek(-30 * time.Second) })
+	m.display.BindKey('.', "", func() { playback.Seek(30 * time.Second) })
+	m.display.BindKey('<', "< > - seek 5min", func() { playback.Seek(-5 * time.Minute) })
+	m.display.BindKey('>', "", func() { playback.Seek(5 * time.Minute) })
+	m.display.BindKey('-', "- + - speed", func() { changeSpeed(-1) })
+	m.display.BindKey('+', "", func() { changeSpeed(1) })
+	m.display.BindKey('=', "", func() { changeSpeed(1) })
+}
+
+func (m *Monitor) updatePlaybackStatus(playback PlaybackSource) {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stopChan:
+			return
+		case <-ticker.C:
+		}
+
+		speed := "max"
+		if playback.Speed() > 0 {
+			speed = fmt.Sprintf("%gx", playback.Speed())
+		}
+		position := playback.Position().Truncate(time.Second)
+		status := fmt.Sprintf("[green]REPLAY[-] %s %s", position, speed)
+		if playback.Paused() {
+			status = fmt.Sprintf("[yellow]REPLAY PAUSED[-] %s %s", position, speed)
+		}
+		m.display.SetStatus(status)
+	}
+}
@@ -0,0 +1,134 @@
+package telemetry
+
+import (
+	"log"
+	"sort"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+type positionTracker struct {
+	seen     bool
+	position int
+	pitting  bool
+	lap      int
+}
+
+type carPosition struct {
+	driver  *models.StandingsData
+	stats   *models.DriverStats
+	tracker *positionTracker
+	from    int
+	to      int
+	pitting bool
+}
+
+// updatePositions runs once all cars of a standings frame are updated, so
+// every position change can be matched with the cars that swapped places.
+// A change is a pit-cycle change when any car involved was in the pit lane
+// in this or the previous frame.
+func (m *Monitor) updatePositions() {
+	cars := make([]carPosition, 0, len(m.drivers))
+	for key, driver := range m.drivers {
+		stats, lapState := m.driverStats[key], m.lapStates[key]
+		if stats == nil || lapState == nil || driver.Position <= 0 {
+			continue
+		}
+		tracker := &lapState.positions
+		cars = append(cars, carPosition{
+			driver:  driver,
+			stats:   stats,
+			tracker: tracker,
+			from:    tracker.position,
+			to:      driver.Position,
+			pitting: tracker.pitting || inPitLane(driver),
+		})
+	}
+	sort.Slice(cars, func(i, j int) bool {
+		return cars[i].to < cars[j].to
+	})
+
+	for i := range cars {
+		car := &cars[i]
+		if !car.tracker.seen || car.from == car.to {
+			continue
+		}
+
+		change := models.PositionChange{
+			Time:        m.now,
+			Lap:         car.driver.LapsCompleted + 1,
+			LapDistance: car.driver.LapDistance,
+			From:        car.from,
+			To:          car.to,
+			Kind:        models.PositionOther,
+		}
+		pitting := car.pitting
+		for j := range cars {
+			other := &cars[j]
+			if j == i || !other.tracker.seen {
+				continue
+			}
+			passed := other.from < car.from && other.to > car.to
+			passedBy := other.from > car.from && other.to < car.to
+			if !passed && !passedBy {
+				continue
+			}
+			change.Cars = append(change.Cars, other.stats.VehicleNumber)
+			pitting = pitting || other.pitting
+			// Each pass is counted from the side of the car that made it.
+			if passed && !car.pitting && !other.pitting {
+				car.stats.Overtakes++
+				other.stats.TimesOvertaken++
+			}
+		}
+		if len(change.Cars) > 0 {
+			change.Kind = models.PositionOnTrack
+			if pitting {
+				change.Kind = models.PositionPitCycle
+			}
+		}
+
+		car.stats.PositionChanges = append(car.stats.PositionChanges, change)
+		if m.positionLogger != nil {
+			if err := m.positionLogger.LogChange(car.stats, change); err != nil {
+				log.Printf("Error writing position change CSV: %v", err)
+			}
+		}
+	}
+
+	for i := range cars {
+		car := &cars[i]
+		// The grid slot is known even when the monitor joins mid-race; the
+		// first position seen is only a fallback.
+		if car.driver.Qualification > 0 {
+			car.stats.StartPosition = car.driver.Qualification
+		} else if !car.tracker.seen {
+			car.stats.StartPosition = car.to
+		}
+		if !car.tracker.seen || car.driver.LapsCompleted != car.tracker.lap {
+			m.logPosition(car, cars)
+		}
+		*car.tracker = positionTracker{
+			seen:     true,
+			position: car.to,
+			pitting:  inPitLane(car.driver),
+			lap:      car.driver.LapsCompleted,
+		}
+	}
+}
+
+// logPosition writes a row of the position-by-lap chart.
+func (m *Monitor) logPosition(car *carPosition, cars []carPosition) {
+	if m.positionLogger == nil {
+		return
+	}
+	classPosition := 1
+	for _, other := range cars {
+		if other.stats.CarClass == car.stats.CarClass && other.to < car.to {
+			classPosition++
+		}
+	}
+	if err := m.positionLogger.LogPosition(car.stats, car.driver.LapsCompleted, car.to, classPosition); err != nil {
+		log.Printf("Error writing position CSV: %v", err)
+	}
+}
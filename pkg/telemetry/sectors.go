@@ -0,0 +1,47 @@
+package telemetry
+
+import (
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+// updateIdealSectors keeps the best of each sector over all valid laps of a
+// car, which may come from different laps, and the theoretical best lap they
+// add up to. The fastest sectors of each class make up the session ideal lap.
+func (m *Monitor) updateIdealSectors(stats *models.DriverStats, lap models.LapRecord) {
+	if !lap.Valid || lap.Sector1 <= 0 || lap.Sector2 <= 0 || lap.Sector3 <= 0 {
+		return
+	}
+
+	stats.IdealSector1 = bestSector(stats.IdealSector1, lap.Sector1)
+	stats.IdealSector2 = bestSector(stats.IdealSector2, lap.Sector2)
+	stats.IdealSector3 = bestSector(stats.IdealSector3, lap.Sector3)
+	stats.TheoreticalBestLap = stats.IdealSector1 + stats.IdealSector2 + stats.IdealSector3
+
+	ideal, ok := m.idealLaps[stats.CarClass]
+	if !ok {
+		ideal = &models.IdealLap{CarClass: stats.CarClass}
+		m.idealLaps[stats.CarClass] = ideal
+	}
+	updateIdealLapSector(&ideal.Sector1, stats, lap, lap.Sector1)
+	updateIdealLapSector(&ideal.Sector2, stats, lap, lap.Sector2)
+	updateIdealLapSector(&ideal.Sector3, stats, lap, lap.Sector3)
+}
+
+func bestSector(best float64, sector float64) float64 {
+	if best <= 0 || sector < best {
+		return sector
+	}
+	return best
+}
+
+func updateIdealLapSector(sector *models.IdealSector, stats *models.DriverStats, lap models.LapRecord, time float64) {
+	if sector.Time > 0 && time >= sector.Time {
+		return
+	}
+	*sector = models.IdealSector{
+		Time:          time,
+		SlotID:        stats.SlotID,
+		DriverName:    lap.DriverName,
+		VehicleNumber: stats.VehicleNumber,
+	}
+}
@@ -0,0 +1,56 @@
+package telemetry
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+type Source interface {
+	Next() (models.Frame, error)
+	Close() error
+}
+
+type PlaybackSource interface {
+	Source
+	TogglePause()
+	Seek(delta time.Duration)
+	SetSpeed(speed float64)
+	Speed() float64
+	Paused() bool
+	Position() time.Duration
+}
+
+type ChannelSource struct {
+	frames    <-chan models.Frame
+	stopChan  chan struct{}
+	closeOnce sync.Once
+}
+
+func NewChannelSource(frames <-chan models.Frame) *ChannelSource {
+	return &ChannelSource{
+		frames:   frames,
+		stopChan: make(chan struct{}),
+	}
+}
+
+func (s *ChannelSource) Next() (models.Frame, error) {
+	select {
+	case frame, ok := <-s.frames:
+		if !ok {
+			return models.Frame{}, io.EOF
+		}
+		return frame, nil
+	case <-s.stopChan:
+		return models.Frame{}, io.EOF
+	}
+}
+
+func (s *ChannelSource) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stopChan)
+	})
+	return nil
+}
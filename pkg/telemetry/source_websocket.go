@@ -0,0 +1,161 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+type WebSocketSource struct {
+	url          string
+	mu           sync.Mutex
+	conn         *websocket.Conn
+	reconnecting bool
+	stopChan     chan struct{}
+	closeOnce    sync.Once
+}
+
+func NewWebSocketSource(host string, port string) *WebSocketSource {
+	return &WebSocketSource{
+		url:      fmt.Sprintf("ws://%s:%s/websocket/controlpanel", host, port),
+		stopChan: make(chan struct{}),
+	}
+}
+
+func (s *WebSocketSource) connect() error {
+	conn, _, err := websocket.DefaultDialer.Dial(s.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to connect to WebSocket: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-s.stopChan:
+		conn.Close()
+		return io.EOF
+	default:
+	}
+	s.conn = conn
+	log.Printf("Connected to WebSocket: %s", s.url)
+	return nil
+}
+
+func (s *WebSocketSource) connectWithRetry() error {
+	backoff := time.Second
+	maxBackoff := 30 * time.Second
+
+	for {
+		select {
+		case <-s.stopChan:
+			return io.EOF
+		default:
+		}
+
+		if !s.reconnecting {
+			log.Printf("Attempting to connect to WebSocket...")
+		} else {
+			log.Printf("Attempting to reconnect to WebSocket...")
+		}
+
+		err := s.connect()
+		if err == nil {
+			if s.reconnecting {
+				log.Printf("Reconnected successfully!")
+				s.reconnecting = false
+			}
+			return nil
+		}
+		if err == io.EOF {
+			return err
+		}
+
+		if !s.reconnecting {
+			log.Printf("Initial connection failed: %v. Retrying in %v...", err, backoff)
+		} else {
+			log.Printf("Reconnection failed: %v. Retrying in %v...", err, backoff)
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff = time.Duration(float64(backoff) * 1.5)
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		case <-s.stopChan:
+			return io.EOF
+		}
+	}
+}
+
+func (s *WebSocketSource) currentConn() *websocket.Conn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn
+}
+
+func (s *WebSocketSource) closeConn() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		if err := s.conn.Close(); err != nil {
+			log.Printf("Error closing WebSocket connection: %v", err)
+		}
+		s.conn = nil
+	}
+}
+
+func (s *WebSocketSource) Next() (models.Frame, error) {
+	for {
+		select {
+		case <-s.stopChan:
+			return models.Frame{}, io.EOF
+		default:
+		}
+
+		conn := s.currentConn()
+		if conn == nil {
+			if err := s.connectWithRetry(); err != nil {
+				return models.Frame{}, err
+			}
+			continue
+		}
+
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			select {
+			case <-s.stopChan:
+				return models.Frame{}, io.EOF
+			default:
+			}
+			log.Printf("WebSocket read error: %v", err)
+			s.closeConn()
+			s.reconnecting = true
+			continue
+		}
+
+		received := time.Now()
+
+		var wsMsg models.WSMessage
+		if err := json.Unmarshal(message, &wsMsg); err != nil {
+			log.Printf("Error unmarshaling WebSocket message: %v", err)
+			continue
+		}
+
+		return models.Frame{Type: wsMsg.Type, Body: wsMsg.Body, Received: received}, nil
+	}
+}
+
+func (s *WebSocketSource) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stopChan)
+	})
+	s.closeConn()
+	return nil
+}
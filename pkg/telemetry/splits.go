@@ -0,0 +1,139 @@
+package telemetry
+
+import (
+	"log"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/trackconfig"
+)
+
+type splitTracker struct {
+	started      bool
+	lastDistance float64
+	lastTime     float64
+	lastSpeed    float64
+	trapSpeeds   []float64
+	sectorEntry  []float64
+	sectorTimes  []float64
+}
+
+func (t *splitTracker) reset(track trackconfig.Track) {
+	t.started = false
+	t.trapSpeeds = make([]float64, len(track.SpeedTraps))
+	t.sectorEntry = make([]float64, len(track.MiniSectors))
+	t.sectorTimes = make([]float64, len(track.MiniSectors))
+	// Time into the lap is zero on the line, so mini-sectors starting there
+	// are entered as the lap begins.
+	for i, sector := range track.MiniSectors {
+		t.sectorEntry[i] = -1
+		if sector.Start <= 0 {
+			t.sectorEntry[i] = 0
+		}
+	}
+}
+
+func (t *splitTracker) matches(track trackconfig.Track) bool {
+	return len(t.trapSpeeds) == len(track.SpeedTraps) && len(t.sectorEntry) == len(track.MiniSectors)
+}
+
+func (m *Monitor) LoadTrackConfig(filename string) error {
+	config, err := trackconfig.Load(filename)
+	if err != nil {
+		return err
+	}
+	m.trackConfig = config
+	return nil
+}
+
+func (m *Monitor) trackDefinition() trackconfig.Track {
+	if m.session == nil {
+		return trackconfig.Track{}
+	}
+	return m.trackConfig[m.session.TrackName]
+}
+
+// updateSplits follows a car along the lap and picks up its speed in each
+// speed trap and the time it crosses the ends of each mini-sector. Samples
+// arrive a few times a second, so crossings are interpolated between them.
+func (m *Monitor) updateSplits(driver *models.StandingsData, tracker *splitTracker) {
+	track := m.trackDefinition()
+	if !tracker.matches(track) {
+		tracker.reset(track)
+	}
+	if len(track.SpeedTraps) == 0 && len(track.MiniSectors) == 0 {
+		return
+	}
+
+	distance := driver.LapDistance
+	lapTime := driver.TimeIntoLap
+	speed := driver.CarVelocity.Velocity * 3.6
+	if !tracker.started {
+		for i, trap := range track.SpeedTraps {
+			if trap.Start <= 0 && distance <= trap.End {
+				tracker.trapSpeeds[i] = speed
+			}
+		}
+	} else if distance > tracker.lastDistance {
+		at := func(target float64, from float64, to float64) float64 {
+			return from + (to-from)*(target-tracker.lastDistance)/(distance-tracker.lastDistance)
+		}
+
+		for i, trap := range track.SpeedTraps {
+			if distance >= trap.Start && distance <= trap.End {
+				tracker.trapSpeeds[i] = max(tracker.trapSpeeds[i], speed)
+			} else if tracker.lastDistance < trap.Start && distance > trap.End {
+				tracker.trapSpeeds[i] = max(tracker.trapSpeeds[i], at(trap.Start, tracker.lastSpeed, speed))
+			}
+		}
+		for i, sector := range track.MiniSectors {
+			if tracker.lastDistance < sector.Start && distance >= sector.Start {
+				tracker.sectorEntry[i] = at(sector.Start, tracker.lastTime, lapTime)
+			}
+			if tracker.sectorEntry[i] >= 0 && tracker.sectorTimes[i] == 0 && tracker.lastDistance < sector.End && distance >= sector.End {
+				tracker.sectorTimes[i] = at(sector.End, tracker.lastTime, lapTime) - tracker.sectorEntry[i]
+			}
+		}
+	}
+
+	if !tracker.started || distance > tracker.lastDistance {
+		tracker.lastDistance = distance
+		tracker.lastTime = lapTime
+		tracker.lastSpeed = speed
+	}
+	tracker.started = true
+}
+
+// completeSplits attaches the speed trap and mini-sector readings to a
+// finished lap. A mini-sector ending at the line is closed with the lap time.
+func (m *Monitor) completeSplits(lap *models.LapRecord, tracker *splitTracker) {
+	track := m.trackDefinition()
+	if !tracker.matches(track) {
+		tracker.reset(track)
+		return
+	}
+
+	for i, trap := range track.SpeedTraps {
+		if tracker.trapSpeeds[i] > 0 {
+			lap.Splits = append(lap.Splits, models.LapSplit{Kind: models.SplitSpeedTrap, Name: trap.Name, Value: tracker.trapSpeeds[i]})
+		}
+	}
+	for i, sector := range track.MiniSectors {
+		sectorTime := tracker.sectorTimes[i]
+		if sectorTime == 0 && tracker.sectorEntry[i] >= 0 && lap.LapTime > 0 && sector.End >= m.session.LapDistance {
+			sectorTime = lap.LapTime - tracker.sectorEntry[i]
+		}
+		if sectorTime > 0 {
+			lap.Splits = append(lap.Splits, models.LapSplit{Kind: models.SplitMiniSector, Name: sector.Name, Value: sectorTime})
+		}
+	}
+	tracker.reset(track)
+}
+
+func (m *Monitor) logSplits(stats *models.DriverStats, lap models.LapRecord) {
+	if m.splitLogger == nil || len(lap.Splits) == 0 {
+		return
+	}
+	if err := m.splitLogger.LogSplits(stats, lap); err != nil {
+		log.Printf("Error writing splits CSV: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package telemetry
+
+import (
+	"log"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+func (m *Monitor) startStint(stats *models.DriverStats, driver *models.StandingsData) {
+	stats.Stints = append(stats.Stints, models.Stint{
+		DriverName: driver.DriverName,
+		SteamID:    driver.SteamID,
+		StartLap:   driver.LapsCompleted,
+		EndLap:     driver.LapsCompleted,
+		StartedAt:  m.now,
+		EndedAt:    m.now,
+	})
+}
+
+func (m *Monitor) endStint(stats *models.DriverStats, driver *models.StandingsData) {
+	stint := currentStint(stats)
+	if stint == nil {
+		return
+	}
+	stint.EndLap = driver.LapsCompleted
+	stint.EndedAt = m.now
+	stint.Finished = true
+	m.logStint(stats, *stint)
+}
+
+func (m *Monitor) updateStint(stats *models.DriverStats, driver *models.StandingsData, lapState *DriverLapState) {
+	stint := currentStint(stats)
+	if stint == nil {
+		return
+	}
+	stint.EndLap = driver.LapsCompleted
+	stint.EndedAt = m.now
+
+	if driver.Pitstops > lapState.lastPitstops {
+		stint.PitStops += driver.Pitstops - lapState.lastPitstops
+	}
+	lapState.lastPitstops = driver.Pitstops
+}
+
+func addLapToStint(stats *models.DriverStats, lap models.LapRecord) {
+	stint := currentStint(stats)
+	if stint == nil {
+		return
+	}
+	stint.Laps++
+	if !lap.Valid || lap.Pitted {
+		return
+	}
+	stint.TimedLaps++
+	stint.AverageLap += (lap.LapTime - stint.AverageLap) / float64(stint.TimedLaps)
+	if stint.BestLap == 0 || lap.LapTime < stint.BestLap {
+		stint.BestLap = lap.LapTime
+	}
+}
+
+func currentStint(stats *models.DriverStats) *models.Stint {
+	if len(stats.Stints) == 0 {
+		return nil
+	}
+	return &stats.Stints[len(stats.Stints)-1]
+}
+
+func (m *Monitor) logStint(stats *models.DriverStats, stint models.Stint) {
+	if m.stintLogger == nil {
+		return
+	}
+	if err := m.stintLogger.LogStint(stats, len(stats.Stints), stint); err != nil {
+		log.Printf("Error writing stint CSV: %v", err)
+	}
+}
+
+func (m *Monitor) logOpenStints() {
+	for _, stats := range m.driverStats {
+		if stint := currentStint(stats); stint != nil && !stint.Finished {
+			m.logStint(stats, *stint)
+		}
+	}
+}
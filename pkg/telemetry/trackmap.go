@@ -0,0 +1,67 @@
+package telemetry
+
+import (
+	"log"
+	"math"
+	"os"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/trackmap"
+)
+
+const trackMapDir = "tracks"
+
+// loadTrackMap picks up the cached outline of the session track, or starts
+// building one from car positions if there is none yet.
+func (m *Monitor) loadTrackMap() {
+	if m.session == nil || m.session.TrackName == "" {
+		return
+	}
+	trackName := m.session.TrackName
+	if (m.trackMap != nil && m.trackMap.TrackName == trackName) ||
+		(m.trackMapBuilder != nil && m.trackMapBuilder.TrackName() == trackName) {
+		return
+	}
+	m.trackMap = nil
+	m.trackMapBuilder = nil
+
+	trackMap, err := trackmap.Load(trackMapDir, trackName)
+	if err == nil {
+		m.trackMap = trackMap
+		log.Printf("Loaded track map for %s", trackName)
+		return
+	}
+	if !os.IsNotExist(err) {
+		log.Printf("Error loading track map: %v", err)
+	}
+	if m.session.LapDistance > 0 {
+		m.trackMapBuilder = trackmap.NewBuilder(trackName, m.session.LapDistance)
+	}
+}
+
+// sampleTrackMap only uses cars on track, within the track edges and on a lap
+// that counts, so the outline follows the racing line.
+func (m *Monitor) sampleTrackMap(driver *models.StandingsData) {
+	if m.trackMapBuilder == nil {
+		return
+	}
+	if inPitLane(driver) || math.Abs(driver.PathLateral) > driver.TrackEdge {
+		return
+	}
+	if driver.CountLapFlag != "" && driver.CountLapFlag != "COUNT_LAP_AND_TIME" {
+		return
+	}
+
+	m.trackMapBuilder.Add(driver.LapDistance, driver.CarPosition.X, driver.CarPosition.Z)
+	if !m.trackMapBuilder.Complete() {
+		return
+	}
+
+	m.trackMap = m.trackMapBuilder.Build()
+	m.trackMapBuilder = nil
+	if err := trackmap.Save(trackMapDir, m.trackMap); err != nil {
+		log.Printf("Error saving track map: %v", err)
+		return
+	}
+	log.Printf("Track map for %s saved", m.trackMap.TrackName)
+}
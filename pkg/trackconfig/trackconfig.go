@@ -0,0 +1,50 @@
+package trackconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// Range is a stretch of the lap between two lap distances in metres. A speed
+// trap with equal start and end measures the speed at that single point.
+type Range struct {
+	Name  string  `json:"name"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+type Track struct {
+	SpeedTraps  []Range `json:"speedTraps"`
+	MiniSectors []Range `json:"miniSectors"`
+}
+
+// Config holds the track definitions keyed by the track name the game
+// reports in the session info.
+type Config map[string]Track
+
+// Load reads the track definitions. A missing file is not an error, it just
+// means no track has any.
+func Load(filename string) (Config, error) {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read track config: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode track config: %w", err)
+	}
+	for trackName, track := range config {
+		for _, r := range slices.Concat(track.SpeedTraps, track.MiniSectors) {
+			if r.Name == "" || r.Start < 0 || r.End < r.Start {
+				return nil, fmt.Errorf("invalid range %q on %s: start %.0f, end %.0f", r.Name, trackName, r.Start, r.End)
+			}
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,129 @@
+package trackmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+const (
+	// Lap distance covered by one point of the outline, in metres.
+	binSize = 10.0
+	// Share of the outline that must be driven before it is usable; gaps are
+	// bridged by the straight line between their neighbours.
+	minCoverage = 0.95
+	minSamples  = 3
+)
+
+type Builder struct {
+	trackName string
+	length    float64
+	sumX      []float64
+	sumZ      []float64
+	count     []int
+	covered   int
+}
+
+func NewBuilder(trackName string, length float64) *Builder {
+	bins := int(math.Ceil(length / binSize))
+	return &Builder{
+		trackName: trackName,
+		length:    length,
+		sumX:      make([]float64, bins),
+		sumZ:      make([]float64, bins),
+		count:     make([]int, bins),
+	}
+}
+
+func (b *Builder) TrackName() string {
+	return b.trackName
+}
+
+// Add records where a car on track was at a given lap distance. Samples of
+// many cars and laps are averaged into each point of the outline.
+func (b *Builder) Add(lapDistance float64, x float64, z float64) {
+	if lapDistance < 0 || lapDistance >= b.length {
+		return
+	}
+	bin := int(lapDistance / binSize)
+	b.sumX[bin] += x
+	b.sumZ[bin] += z
+	b.count[bin]++
+	if b.count[bin] == minSamples {
+		b.covered++
+	}
+}
+
+func (b *Builder) Complete() bool {
+	return float64(b.covered) >= minCoverage*float64(len(b.count))
+}
+
+func (b *Builder) Build() *models.TrackMap {
+	trackMap := &models.TrackMap{
+		TrackName: b.trackName,
+		Length:    b.length,
+	}
+	for bin, count := range b.count {
+		if count == 0 {
+			continue
+		}
+		trackMap.Points = append(trackMap.Points, models.TrackPoint{
+			Distance: (float64(bin) + 0.5) * binSize,
+			X:        b.sumX[bin] / float64(count),
+			Z:        b.sumZ[bin] / float64(count),
+		})
+	}
+	return trackMap
+}
+
+func Load(dir string, trackName string) (*models.TrackMap, error) {
+	data, err := os.ReadFile(filename(dir, trackName))
+	if err != nil {
+		return nil, err
+	}
+	var trackMap models.TrackMap
+	if err := json.Unmarshal(data, &trackMap); err != nil {
+		return nil, fmt.Errorf("failed to decode track map: %w", err)
+	}
+	return &trackMap, nil
+}
+
+func Save(dir string, trackMap *models.TrackMap) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create track map directory: %w", err)
+	}
+	data, err := json.Marshal(trackMap)
+	if err != nil {
+		return fmt.Errorf("failed to encode track map: %w", err)
+	}
+
+	// Write next to the target and rename over it, so a crash never leaves a
+	// partial map that would be loaded in the next session.
+	target := filename(dir, trackMap.TrackName)
+	tmpFilename := target + ".tmp"
+	if err := os.WriteFile(tmpFilename, data, 0644); err != nil {
+		os.Remove(tmpFilename)
+		return fmt.Errorf("failed to write track map: %w", err)
+	}
+	if err := os.Rename(tmpFilename, target); err != nil {
+		os.Remove(tmpFilename)
+		return fmt.Errorf("failed to replace track map: %w", err)
+	}
+	return nil
+}
+
+func filename(dir string, trackName string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' {
+			return r
+		}
+		return '_'
+	}, trackName)
+	return filepath.Join(dir, name+".json")
+}
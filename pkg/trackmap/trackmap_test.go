@@ -0,0 +1,45 @@
+package trackmap
+
+import (
+	"math"
+	"os"
+	"testing"
+)
+
+func TestBuilderCompletesAndRoundTrips(t *testing.T) {
+	const length = 1000.0
+	builder := NewBuilder("Circuit de la Sarthe", length)
+
+	for lap := 0; lap < 3; lap++ {
+		for distance := 0.0; distance < length; distance += 5 {
+			if builder.Complete() {
+				break
+			}
+			angle := 2 * math.Pi * distance / length
+			builder.Add(distance, 100*math.Cos(angle), 100*math.Sin(angle))
+		}
+	}
+	if !builder.Complete() {
+		t.Fatalf("outline not complete after three laps")
+	}
+
+	trackMap := builder.Build()
+	if len(trackMap.Points) != 100 {
+		t.Fatalf("outline has %d points, want 100", len(trackMap.Points))
+	}
+
+	dir := t.TempDir()
+	if err := Save(dir, trackMap); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 1 || entries[0].Name() != "Circuit_de_la_Sarthe.json" {
+		t.Fatalf("expected only the saved map in %s, got %v (%v)", dir, entries, err)
+	}
+	loaded, err := Load(dir, "Circuit de la Sarthe")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.TrackName != trackMap.TrackName || loaded.Length != length || len(loaded.Points) != len(trackMap.Points) {
+		t.Errorf("loaded map differs: %s %.0fm %d points", loaded.TrackName, loaded.Length, len(loaded.Points))
+	}
+}
@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+type classGroup struct {
+	name    string
+	drivers []*models.StandingsData
+	fastest *models.StandingsData
+}
+
+// groupByClass splits a position-sorted driver list into classes, ordered by
+// the position of each class leader.
+func groupByClass(driverList []*models.StandingsData) []*classGroup {
+	var groups []*classGroup
+	byName := make(map[string]*classGroup)
+	for _, driver := range driverList {
+		group, ok := byName[driver.CarClass]
+		if !ok {
+			group = &classGroup{name: driver.CarClass}
+			byName[driver.CarClass] = group
+			groups = append(groups, group)
+		}
+		group.drivers = append(group.drivers, driver)
+		if driver.BestLapTime > 0 && (group.fastest == nil || driver.BestLapTime < group.fastest.BestLapTime) {
+			group.fastest = driver
+		}
+	}
+	return groups
+}
+
+func (d *Display) toggleClassView() {
+	d.classView = !d.classView
+	if d.classView {
+		d.driversBox.SetTitle(" [::b]All Drivers - By Class[::-] ")
+	} else {
+		d.driversBox.SetTitle(" [::b]All Drivers - Live Data[::-] ")
+	}
+	if d.current != nil {
+		d.UpdateDrivers(d.current.Drivers)
+	}
+}
+
+func formatClassHeader(group *classGroup) string {
+	leader := group.drivers[0]
+	header := fmt.Sprintf("[aqua][::b]%s[::-][-] - %d cars, leader #%s %s",
+		group.name, len(group.drivers), leader.VehicleNumber, leader.DriverName)
+	if group.fastest != nil {
+		header += fmt.Sprintf(", fastest [purple]%s[-] #%s %s",
+			formatTime(group.fastest.BestLapTime), group.fastest.VehicleNumber, group.fastest.DriverName)
+	}
+	return header + "\n"
+}
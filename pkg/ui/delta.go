@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+const (
+	deltaBarWidth = 40
+	// Deltas beyond this many seconds fill the bar.
+	deltaBarRange = 2.0
+)
+
+// focusedDriver returns the player car, or the car the camera follows when
+// spectating.
+func focusedDriver(drivers map[int]*models.StandingsData) *models.StandingsData {
+	var focused *models.StandingsData
+	for _, driver := range drivers {
+		if driver.Player || (focused == nil && driver.HasFocus) {
+			focused = driver
+		}
+	}
+	return focused
+}
+
+func (d *Display) UpdateDelta(drivers map[int]*models.StandingsData, stats map[int]*models.DriverStats) {
+	driver := focusedDriver(drivers)
+	if driver == nil {
+		d.deltaBox.SetText("No player car...")
+		return
+	}
+	stat := stats[driver.SlotID]
+	if stat == nil {
+		d.deltaBox.SetText("Waiting for data...")
+		return
+	}
+
+	var deltaText strings.Builder
+	deltaText.WriteString(fmt.Sprintf("[yellow][::b]#%s[::-][-] %s\n", stat.VehicleNumber, truncate(driver.DriverName, 36)))
+	if !stat.Delta.Active {
+		deltaText.WriteString(fmt.Sprintf(" Reference %s\n [gray]Waiting for a clean lap...[-]", formatTime(stat.Delta.ReferenceLap)))
+		d.deltaBox.SetText(deltaText.String())
+		return
+	}
+
+	color := "green"
+	if stat.Delta.Delta > 0 {
+		color = "red"
+	}
+	deltaText.WriteString(fmt.Sprintf(" Delta [%s][::b]%+.3f[::-][-]   Reference %s\n", color, stat.Delta.Delta, formatTime(stat.Delta.ReferenceLap)))
+	deltaText.WriteString(" " + deltaBar(stat.Delta.Delta))
+	d.deltaBox.SetText(deltaText.String())
+}
+
+// deltaBar draws the delta from the middle of the bar: to the left in green
+// when ahead of the reference, to the right in red when behind.
+func deltaBar(delta float64) string {
+	half := deltaBarWidth / 2
+	filled := int(math.Round(math.Min(math.Abs(delta)/deltaBarRange, 1) * float64(half)))
+
+	left := strings.Repeat("─", half)
+	right := strings.Repeat("─", half)
+	if delta < 0 {
+		left = strings.Repeat("─", half-filled) + "[green]" + strings.Repeat("█", filled) + "[-]"
+	} else {
+		right = "[red]" + strings.Repeat("█", filled) + "[-]" + strings.Repeat("─", half-filled)
+	}
+	return left + "│" + right
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
@@ -18,20 +19,64 @@ type Display struct {
 	sessionBox        *tview.TextView
 	driversBox        *tview.TextView
 	statsBox          *tview.TextView
+	fuelBox           *tview.TextView
+	deltaBox          *tview.TextView
+	idealBox          *tview.TextView
+	mapBox            *tview.TextView
+	incidentsBox      *tview.TextView
+	eventsBox         *tview.TextView
 	versionBox        *tview.TextView
+	sidebar           *tview.Flex
 	grid              *tview.Grid
 	frame             *tview.Frame
 	fullscreenDrivers bool
 	fullscreenStats   bool
+	classView         bool
 	prevFocus         tview.Primitive
+	keyBindings       map[rune]func()
+	extraHelp         []string
+	mu                sync.Mutex
+	pending           *models.Snapshot
+	pendingStatus     *string
+	scheduled         bool
+	current           *models.Snapshot
+	selectedSlot      int
+	hasSelection      bool
+	flashes           map[flashKey]flashState
+	shownEvents       int
+	lastEvent         models.RaceEvent
 }
 
 func NewDisplay() *Display {
 	return &Display{
-		app: tview.NewApplication(),
+		app:          tview.NewApplication(),
+		keyBindings:  make(map[rune]func()),
+		selectedSlot: -1,
+		flashes:      make(map[flashKey]flashState),
+		shownEvents:  -1,
 	}
 }
 
+func (d *Display) BindKey(key rune, help string, handler func()) {
+	d.keyBindings[key] = handler
+	if help != "" {
+		d.extraHelp = append(d.extraHelp, help)
+	}
+}
+
+func (d *Display) helpText(fullscreenDrivers bool, fullscreenStats bool) string {
+	drivers := "F - fullscreen drivers"
+	if fullscreenDrivers {
+		drivers = "[::b]" + drivers + "[::-]"
+	}
+	stats := "S - fullscreen stats"
+	if fullscreenStats {
+		stats = "[::b]" + stats + "[::-]"
+	}
+	parts := append([]string{"Press Ctrl+C or Q to quit", drivers, stats, "C - class view", "N/P - select car"}, d.extraHelp...)
+	return strings.Join(parts, " | ")
+}
+
 func (d *Display) Setup() {
 	d.sessionBox = tview.NewTextView()
 	d.sessionBox.SetBorder(true).SetTitle(" [::b]Session Info[::-] ").SetTitleAlign(tview.AlignLeft)
@@ -43,21 +88,55 @@ func (d *Display) Setup() {
 
 	d.statsBox = tview.NewTextView()
 	d.statsBox.SetBorder(true).SetTitle(" [::b]Driver Statistics & Records[::-] ").SetTitleAlign(tview.AlignLeft)
-	d.statsBox.SetDynamicColors(true)
+	d.statsBox.SetDynamicColors(true).SetWrap(false)
+
+	d.fuelBox = tview.NewTextView()
+	d.fuelBox.SetBorder(true).SetTitle(" [::b]Fuel[::-] ").SetTitleAlign(tview.AlignLeft)
+	d.fuelBox.SetDynamicColors(true)
+
+	d.deltaBox = tview.NewTextView()
+	d.deltaBox.SetBorder(true).SetTitle(" [::b]Lap Delta[::-] ").SetTitleAlign(tview.AlignLeft)
+	d.deltaBox.SetDynamicColors(true)
+
+	d.idealBox = tview.NewTextView()
+	d.idealBox.SetBorder(true).SetTitle(" [::b]Ideal Lap by Class[::-] ").SetTitleAlign(tview.AlignLeft)
+	d.idealBox.SetDynamicColors(true)
+
+	d.mapBox = tview.NewTextView()
+	d.mapBox.SetBorder(true).SetTitle(" [::b]Track Map[::-] ").SetTitleAlign(tview.AlignLeft)
+	d.mapBox.SetDynamicColors(true).SetWrap(false)
+
+	d.incidentsBox = tview.NewTextView()
+	d.incidentsBox.SetBorder(true).SetTitle(" [::b]Incidents[::-] ").SetTitleAlign(tview.AlignLeft)
+	d.incidentsBox.SetDynamicColors(true)
+
+	d.eventsBox = tview.NewTextView()
+	d.eventsBox.SetBorder(true).SetTitle(" [::b]Race Control[::-] ").SetTitleAlign(tview.AlignLeft)
+	d.eventsBox.SetDynamicColors(true)
+
+	d.sidebar = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(d.deltaBox, 5, 0, false).
+		AddItem(d.fuelBox, 14, 0, false).
+		AddItem(d.mapBox, 0, 2, false).
+		AddItem(d.idealBox, 0, 1, false).
+		AddItem(d.incidentsBox, 0, 1, false).
+		AddItem(d.eventsBox, 0, 1, false)
 
 	d.grid = tview.NewGrid().
 		SetRows(3, 0, 0).
+		SetColumns(0, 46).
 		SetBorders(true)
 
-	d.grid.AddItem(d.sessionBox, 0, 0, 1, 1, 0, 0, false).
+	d.grid.AddItem(d.sessionBox, 0, 0, 1, 2, 0, 0, false).
 		AddItem(d.driversBox, 1, 0, 1, 1, 0, 0, true).
-		AddItem(d.statsBox, 2, 0, 1, 1, 0, 0, true)
+		AddItem(d.statsBox, 2, 0, 1, 1, 0, 0, true).
+		AddItem(d.sidebar, 1, 1, 2, 1, 0, 0, false)
 
 	d.frame = tview.NewFrame(d.grid).
 		SetBorders(0, 0, 0, 0, 0, 0).
 		AddText(fmt.Sprintf("[::b]LMU Racing Telemetry[::-] %s", Version), true, tview.AlignCenter, tcell.ColorLightBlue).
 		AddText(fmt.Sprintf("Copyright (C) %s Marek Słomnicki <marek@slomnicki.net>", Year), false, tview.AlignCenter, tcell.ColorBlue).
-		AddText("Press Ctrl+C or Q to quit | F - fullscreen drivers | S - fullscreen stats", false, tview.AlignCenter, tcell.ColorGray)
+		AddText(d.helpText(false, false), false, tview.AlignCenter, tcell.ColorGray)
 
 	d.app.SetRoot(d.frame, true).EnableMouse(true)
 
@@ -74,10 +153,79 @@ func (d *Display) Setup() {
 			d.toggleStatsFullscreen()
 			return nil
 		}
+		if event.Rune() == 'c' || event.Rune() == 'C' {
+			d.toggleClassView()
+			return nil
+		}
+		if event.Rune() == 'n' || event.Rune() == 'N' {
+			d.selectCar(1)
+			return nil
+		}
+		if event.Rune() == 'p' || event.Rune() == 'P' {
+			d.selectCar(-1)
+			return nil
+		}
+		if handler, ok := d.keyBindings[event.Rune()]; ok && event.Key() == tcell.KeyRune {
+			handler()
+			return nil
+		}
 		return event
 	})
 }
 
+func (d *Display) SetScreen(screen tcell.Screen) {
+	d.app.SetScreen(screen)
+}
+
+// Update hands a snapshot to the UI goroutine. It is safe to call from any
+// goroutine and never blocks; snapshots arriving faster than the screen
+// redraws are coalesced so only the latest one is rendered.
+func (d *Display) Update(snapshot *models.Snapshot) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending = snapshot
+	d.schedule()
+}
+
+func (d *Display) schedule() {
+	if d.scheduled {
+		return
+	}
+	d.scheduled = true
+	// QueueUpdateDraw waits for the event loop, which may not be running yet
+	// or may already have stopped.
+	go d.app.QueueUpdateDraw(d.applyPending)
+}
+
+func (d *Display) applyPending() {
+	d.mu.Lock()
+	snapshot := d.pending
+	status := d.pendingStatus
+	d.pending = nil
+	d.pendingStatus = nil
+	d.scheduled = false
+	d.mu.Unlock()
+
+	if status != nil {
+		d.sessionBox.SetTitle(*status)
+	}
+	if snapshot != nil {
+		d.current = snapshot
+		d.UpdateSession(snapshot.Session)
+		d.UpdateDrivers(snapshot.Drivers)
+		d.UpdateStats(snapshot.Stats)
+		d.UpdateDelta(snapshot.Drivers, snapshot.Stats)
+		d.UpdateFuel(snapshot.Drivers, snapshot.Stats)
+		d.UpdateIdealLaps(snapshot.IdealLaps)
+		d.UpdateTrackMap(snapshot.TrackMap, snapshot.Drivers)
+		d.UpdateIncidents(snapshot.Incidents)
+		d.UpdateEvents(snapshot.Events)
+	}
+}
+
+// The Update* methods below change widgets directly and must only run on the
+// UI goroutine, i.e. from Update or other queued updates.
+
 func (d *Display) UpdateSession(session *models.SessionData) {
 	if session == nil {
 		return
@@ -98,7 +246,18 @@ func (d *Display) UpdateSession(session *models.SessionData) {
 	d.sessionBox.SetText(sessionText)
 }
 
-func (d *Display) UpdateDrivers(drivers map[string]*models.StandingsData) {
+func (d *Display) SetStatus(status string) {
+	title := " [::b]Session Info[::-] "
+	if status != "" {
+		title = fmt.Sprintf(" [::b]Session Info[::-] - %s ", status)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pendingStatus = &title
+	d.schedule()
+}
+
+func (d *Display) UpdateDrivers(drivers map[int]*models.StandingsData) {
 	driverList := make([]*models.StandingsData, 0, len(drivers))
 	for _, driver := range drivers {
 		driverList = append(driverList, driver)
@@ -157,43 +316,101 @@ func (d *Display) UpdateDrivers(drivers map[string]*models.StandingsData) {
 		maxStatus = 20
 	}
 
-	headerFormat := fmt.Sprintf("[yellow][::b]%%-%ds %%-%ds %%-%ds %%-%ds %%-%ds %%-%ds %%-%ds %%-%ds %%-%ds %%-%ds[::-][-]\n",
-		3, maxDriverName, maxClassName, maxVehicleNumber, maxVehicleModel, 4, 8, 8, 6, maxStatus)
-	dataFormat := fmt.Sprintf("%%-%dd %%-%ds %%-%ds %%%ds %%-%ds %%-%dd %%-%ds %%-%ds %%-%d.0f %%-%ds\n",
-		3, maxDriverName, maxClassName, maxVehicleNumber, maxVehicleModel, 4, 8, 8, 6, maxStatus)
+	headerFormat := fmt.Sprintf("[yellow][::b]%%-%ds %%-%ds %%-%ds %%-%ds %%-%ds %%-%ds %%%ds %%%ds %%%ds %%%ds %%-%ds %%-%ds %%-%ds %%-%ds %%-%ds %%-%ds %%-%ds %%-%ds[::-][-]\n",
+		3, maxDriverName, maxClassName, maxVehicleNumber, maxVehicleModel, 4, 9, 9, 9, 9, 8, 8, 8, 8, 8, 8, 6, maxStatus)
+	dataFormat := fmt.Sprintf("%%-%dd %%-%ds %%-%ds %%%ds %%-%ds %%-%dd %%%ds %%%ds %%%ds %%%ds %%-%ds %%-%ds %%-%ds %%-%ds %%-%ds %%-%ds %%-%d.0f %%-%ds\n",
+		3, maxDriverName, maxClassName, maxVehicleNumber, maxVehicleModel, 4, 9, 9, 9, 9, 8, 8, 8, 8, 8, 8, 6, maxStatus)
 
 	var driversText strings.Builder
 
+	position := "Pos"
+	if d.classView {
+		position = "PIC"
+	}
 	driversText.WriteString(fmt.Sprintf(headerFormat,
-		"Pos", "Driver", "Class", "No.", "Vehicle", "Laps", "CurLap", "BestLap", "Speed", "Status"))
-	totalWidth := 3 + 1 + maxDriverName + 1 + maxClassName + 1 + maxVehicleNumber + 1 + maxVehicleModel + 1 + 4 + 1 + 8 + 1 + 8 + 1 + 6 + 1 + maxStatus
+		position, "Driver", "Class", "No.", "Vehicle", "Laps", "Gap", "Int", "ClsGap", "ClsInt", "CurLap", "BestLap", "LastLap", "S1", "S2", "S3", "Speed", "Status"))
+	totalWidth := 3 + 1 + maxDriverName + 1 + maxClassName + 1 + maxVehicleNumber + 1 + maxVehicleModel + 1 + 4 + 1 + 4*(9+1) + 6*(8+1) + 6 + 1 + maxStatus
 	driversText.WriteString(strings.Repeat("-", totalWidth) + "\n")
 
-	for _, driver := range driverList {
+	gaps := classGaps(driverList)
+	bests := newTimingBests(d.current)
+	var stats map[int]*models.DriverStats
+	if d.current != nil {
+		stats = d.current.Stats
+	}
+	writeLine := func(position int, driver *models.StandingsData) {
 		status := driver.PitState
 		if driver.Flag != "" && driver.Flag != "green" {
 			status = driver.Flag
 		}
 
+		stat := stats[driver.SlotID]
+		bestLap := colorTime(fmt.Sprintf("%-8s", formatTime(driver.BestLapTime)),
+			bests.lapColor(driver.CarClass, driver.BestLapTime, stat),
+			d.flashing(driver.SlotID, "best", driver.BestLapTime))
+
+		lastLapColor := ""
+		if stat != nil && len(stat.Laps) > 0 && stat.Laps[len(stat.Laps)-1].Valid {
+			lastLapColor = bests.lapColor(driver.CarClass, driver.LastLapTime, stat)
+		}
+		lastLap := colorTime(fmt.Sprintf("%-8s", formatTime(driver.LastLapTime)), lastLapColor,
+			d.flashing(driver.SlotID, "last", driver.LastLapTime))
+
+		var sectors [3]string
+		times, current := liveSectors(driver)
+		for i, sector := range times {
+			color := ""
+			if current || lastLapColor != "" {
+				color = bests.sectorColor(driver.CarClass, i, sector, stat)
+			}
+			sectors[i] = colorTime(fmt.Sprintf("%-8s", formatSector(sector)), color,
+				d.flashing(driver.SlotID, fmt.Sprintf("s%d", i+1), sector))
+		}
+
+		gap := gaps[driver.SlotID]
 		line := fmt.Sprintf(dataFormat,
-			driver.Position,
+			position,
 			truncate(driver.DriverName, maxDriverName),
 			truncate(driver.CarClass, maxClassName),
 			truncate(driver.VehicleNumber, maxVehicleNumber),
 			truncate(driver.VehicleModel, maxVehicleModel),
 			driver.LapsCompleted,
+			formatGap(driver.LapsBehindLeader, driver.TimeBehindLeader),
+			formatGap(driver.LapsBehindNext, driver.TimeBehindNext),
+			formatGap(gap.gapLaps, gap.gap),
+			formatGap(gap.intervalLaps, gap.interval),
 			formatTime(driver.TimeIntoLap),
-			formatTime(driver.BestLapTime),
+			bestLap,
+			lastLap,
+			sectors[0],
+			sectors[1],
+			sectors[2],
 			driver.CarVelocity.Velocity*3.6,
 			truncate(status, maxStatus),
 		)
+		if d.hasSelection && driver.SlotID == d.selectedSlot {
+			line = "[::r]" + strings.TrimSuffix(line, "\n") + "[::-]\n"
+		}
 		driversText.WriteString(line)
 	}
 
+	if d.classView {
+		for _, group := range groupByClass(driverList) {
+			driversText.WriteString(formatClassHeader(group))
+			for i, driver := range group.drivers {
+				writeLine(i+1, driver)
+			}
+		}
+	} else {
+		for _, driver := range driverList {
+			writeLine(driver.Position, driver)
+		}
+	}
+
 	d.driversBox.SetText(driversText.String())
 }
 
-func (d *Display) UpdateStats(stats map[string]*models.DriverStats) {
+func (d *Display) UpdateStats(stats map[int]*models.DriverStats) {
 	statsList := make([]*models.DriverStats, 0, len(stats))
 	for _, stat := range stats {
 		statsList = append(statsList, stat)
@@ -252,35 +469,55 @@ func (d *Display) UpdateStats(stats map[string]*models.DriverStats) {
 		maxVehicleNumber = 4
 	}
 
-	headerFormat := fmt.Sprintf("[yellow][::b]%%-%ds %%-%ds %%-%ds %%-%ds %%6s %%8s %%8s %%8s %%8s %%7s %%8s %%8s %%8s %%8s %%6s[::-][-]\n",
+	headerFormat := fmt.Sprintf("[yellow][::b]%%-%ds %%-%ds %%-%ds %%-%ds %%6s %%8s %%8s %%8s %%8s %%7s %%8s %%8s %%8s %%8s %%6s %%8s %%7s %%-9s %%-11s %%5s %%5s %%5s %%6s[::-][-]\n",
 		maxDriverName, maxClassName, maxVehicleNumber, maxVehicleModel)
-	dataFormat := fmt.Sprintf("%%-%ds %%-%ds %%%ds %%-%ds %%6.1f %%8s %%8s %%8s %%8s %%7.1f %%8s %%8s %%8s %%8s %%6.1f\n",
+	dataFormat := fmt.Sprintf("%%-%ds %%-%ds %%%ds %%-%ds %%6.1f %%8s %%8s %%8s %%8s %%7.1f %%8s %%8s %%8s %%8s %%6.1f %%8s %%7s %%-9s %%-11s %%5d %%5s %%5d %%6d\n",
 		maxDriverName, maxClassName, maxVehicleNumber, maxVehicleModel)
 
 	var statsText strings.Builder
 
 	statsText.WriteString(fmt.Sprintf(headerFormat,
-		"Driver", "Class", "No.", "Vehicle", "MaxSpd", "BestLap", "BestS1", "BestS2", "BestS3", "MaxSpdC", "BestLapC", "BestS1C", "BestS2C", "BestS3C", "MaxSpdBC"))
-	totalWidth := maxDriverName + 1 + maxClassName + 1 + maxVehicleNumber + 1 + maxVehicleModel + 1 + 6 + 1 + 8 + 1 + 8 + 1 + 8 + 1 + 8 + 1 + 7 + 1 + 8 + 1 + 8 + 1 + 8 + 1 + 8 + 1 + 6
+		"Driver", "Class", "No.", "Vehicle", "MaxSpd", "BestLap", "BestS1", "BestS2", "BestS3", "MaxSpdC", "BestLapC", "BestS1C", "BestS2C", "BestS3C", "MaxSpdBC", "TheoBest", "ToTheo", "Stint", "LastPit", "Start", "Gain", "Ovtk", "OvtkBy"))
+	totalWidth := maxDriverName + 1 + maxClassName + 1 + maxVehicleNumber + 1 + maxVehicleModel + 1 + 6 + 1 + 8 + 1 + 8 + 1 + 8 + 1 + 8 + 1 + 7 + 1 + 8 + 1 + 8 + 1 + 8 + 1 + 8 + 1 + 6 + 1 + 8 + 1 + 7 + 1 + 9 + 1 + 11 + 1 + 5 + 1 + 5 + 1 + 5 + 1 + 6
 	statsText.WriteString(strings.Repeat("-", totalWidth) + "\n")
 
+	bests := newTimingBests(d.current)
 	for _, stat := range statsList {
+		lapCell := func(column string, value float64) string {
+			return colorTime(fmt.Sprintf("%8s", formatTime(value)),
+				bests.lapColor(stat.CarClass, value, stat),
+				d.flashing(stat.SlotID, column, value))
+		}
+		sectorCell := func(column string, sector int, value float64) string {
+			return colorTime(fmt.Sprintf("%8s", formatTime(value)),
+				bests.sectorColor(stat.CarClass, sector, value, stat),
+				d.flashing(stat.SlotID, column, value))
+		}
+
 		line := fmt.Sprintf(dataFormat,
 			truncate(stat.DriverName, maxDriverName),
 			truncate(stat.CarClass, maxClassName),
 			truncate(stat.VehicleNumber, maxVehicleNumber),
 			truncate(stat.VehicleModel, maxVehicleModel),
 			stat.MaxSpeed,
-			formatTime(stat.BestLapTime),
-			formatTime(stat.BestSector1),
-			formatTime(stat.BestSector2),
-			formatTime(stat.BestSector3),
+			lapCell("statsBest", stat.BestLapTime),
+			sectorCell("statsBestS1", 0, stat.BestSector1),
+			sectorCell("statsBestS2", 1, stat.BestSector2),
+			sectorCell("statsBestS3", 2, stat.BestSector3),
 			stat.MaxSpeedOnBestLapCalc,
-			formatTime(stat.BestLapTimeCalculated),
-			formatTime(stat.BestSector1Calculated),
-			formatTime(stat.BestSector2Calculated),
-			formatTime(stat.BestSector3Calculated),
+			lapCell("statsBestC", stat.BestLapTimeCalculated),
+			sectorCell("statsBestS1C", 0, stat.BestSector1Calculated),
+			sectorCell("statsBestS2C", 1, stat.BestSector2Calculated),
+			sectorCell("statsBestS3C", 2, stat.BestSector3Calculated),
 			stat.MaxSpeedOnBestLapCalc,
+			formatTime(stat.TheoreticalBestLap),
+			formatTimeLost(stat),
+			formatStint(stat),
+			formatLastPitStop(stat),
+			stat.StartPosition,
+			formatPositionsGained(stat.PositionsGained()),
+			stat.Overtakes,
+			stat.TimesOvertaken,
 		)
 		statsText.WriteString(line)
 	}
@@ -288,10 +525,6 @@ func (d *Display) UpdateStats(stats map[string]*models.DriverStats) {
 	d.statsBox.SetText(statsText.String())
 }
 
-func (d *Display) Draw() {
-	d.app.Draw()
-}
-
 func (d *Display) Run() error {
 	return d.app.Run()
 }
@@ -308,7 +541,7 @@ func (d *Display) toggleDriversFullscreen() {
 			SetBorders(0, 0, 0, 0, 0, 0).
 			AddText(fmt.Sprintf("[::b]LMU Racing Telemetry[::-] %s", Version), true, tview.AlignCenter, tcell.ColorLightBlue).
 			AddText(fmt.Sprintf("Copyright (C) %s Marek Słomnicki <marek@slomnicki.net>", Year), false, tview.AlignCenter, tcell.ColorBlue).
-			AddText("Press Ctrl+C or Q to quit | [::b]F - fullscreen drivers[::-] | S - fullscreen stats", false, tview.AlignCenter, tcell.ColorGray)
+			AddText(d.helpText(true, false), false, tview.AlignCenter, tcell.ColorGray)
 		d.app.SetRoot(fullscreenFrame, true)
 		d.app.SetFocus(d.driversBox)
 		d.fullscreenDrivers = true
@@ -329,7 +562,7 @@ func (d *Display) toggleStatsFullscreen() {
 			SetBorders(0, 0, 0, 0, 0, 0).
 			AddText(fmt.Sprintf("[::b]LMU Racing Telemetry[::-] %s", Version), true, tview.AlignCenter, tcell.ColorLightBlue).
 			AddText(fmt.Sprintf("Copyright (C) %s Marek Słomnicki <marek@slomnicki.net>", Year), false, tview.AlignCenter, tcell.ColorBlue).
-			AddText("Press Ctrl+C or Q to quit | F - fullscreen drivers | [::b]S - fullscreen stats[::-]", false, tview.AlignCenter, tcell.ColorGray)
+			AddText(d.helpText(false, true), false, tview.AlignCenter, tcell.ColorGray)
 		d.app.SetRoot(fullscreenFrame, true)
 		d.app.SetFocus(d.statsBox)
 		d.fullscreenStats = true
@@ -342,6 +575,43 @@ func (d *Display) toggleStatsFullscreen() {
 	}
 }
 
+func formatStint(stat *models.DriverStats) string {
+	if len(stat.Stints) == 0 {
+		return "-"
+	}
+	current := stat.Stints[len(stat.Stints)-1]
+	return fmt.Sprintf("#%d %dL", len(stat.Stints), current.Laps)
+}
+
+func formatTimeLost(stat *models.DriverStats) string {
+	bestLap := stat.BestLapTime
+	if bestLap <= 0 {
+		bestLap = stat.BestLapTimeCalculated
+	}
+	if bestLap <= 0 || stat.TheoreticalBestLap <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("+%.3f", max(bestLap-stat.TheoreticalBestLap, 0))
+}
+
+func formatPositionsGained(gained int) string {
+	switch {
+	case gained > 0:
+		return fmt.Sprintf("[green]%5s[-]", fmt.Sprintf("+%d", gained))
+	case gained < 0:
+		return fmt.Sprintf("[red]%5d[-]", gained)
+	}
+	return "0"
+}
+
+func formatLastPitStop(stat *models.DriverStats) string {
+	if len(stat.PitStops) == 0 {
+		return "-"
+	}
+	stop := stat.PitStops[len(stat.PitStops)-1]
+	return fmt.Sprintf("%.1f/%.1fs", stop.PitLaneTime, stop.StationaryTime)
+}
+
 func truncate(s string, length int) string {
 	if len(s) <= length {
 		return s
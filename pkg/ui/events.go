@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+var eventColors = map[string]string{
+	models.EventSectorYellow:     "yellow",
+	models.EventFullCourseYellow: "yellow",
+	models.EventPhase:            "green",
+	models.EventBlueFlag:         "blue",
+	models.EventPenalty:          "orange",
+	models.EventRetired:          "red",
+	models.EventDisqualified:     "red",
+	models.EventLeadChange:       "aqua",
+}
+
+// UpdateEvents shows the race control log oldest first and keeps it scrolled
+// to the newest message. It only redraws when events were added, so scrolling
+// back with the mouse is not undone by every snapshot.
+func (d *Display) UpdateEvents(events []models.RaceEvent) {
+	var last models.RaceEvent
+	if len(events) > 0 {
+		last = events[len(events)-1]
+	}
+	if len(events) == d.shownEvents && last == d.lastEvent {
+		return
+	}
+	d.shownEvents, d.lastEvent = len(events), last
+
+	if len(events) == 0 {
+		d.eventsBox.SetText("No race control messages yet...")
+		return
+	}
+
+	var eventsText strings.Builder
+	for _, event := range events {
+		car := ""
+		if event.SlotID >= 0 {
+			car = fmt.Sprintf("#%-3s %s ", truncate(event.VehicleNumber, 3), truncate(event.DriverName, 13))
+		}
+		eventsText.WriteString(fmt.Sprintf("%s %s[%s]%s[-]\n",
+			event.Time.Format("15:04:05"),
+			car,
+			eventColors[event.Kind],
+			event.Message,
+		))
+	}
+	d.eventsBox.SetText(strings.TrimSuffix(eventsText.String(), "\n"))
+	d.eventsBox.ScrollToEnd()
+}
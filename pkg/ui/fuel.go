@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+func (d *Display) selectCar(step int) {
+	if d.current == nil || len(d.current.Drivers) == 0 {
+		return
+	}
+
+	driverList := make([]*models.StandingsData, 0, len(d.current.Drivers))
+	for _, driver := range d.current.Drivers {
+		driverList = append(driverList, driver)
+	}
+	sort.Slice(driverList, func(i, j int) bool {
+		return driverList[i].Position < driverList[j].Position
+	})
+
+	index := -1
+	for i, driver := range driverList {
+		if d.hasSelection && driver.SlotID == d.selectedSlot {
+			index = i
+		}
+	}
+	if index == -1 && step < 0 {
+		index = 0
+	}
+	index = (index + step + len(driverList)) % len(driverList)
+
+	d.selectedSlot = driverList[index].SlotID
+	d.hasSelection = true
+	d.UpdateDrivers(d.current.Drivers)
+	d.UpdateFuel(d.current.Drivers, d.current.Stats)
+}
+
+func (d *Display) UpdateFuel(drivers map[int]*models.StandingsData, stats map[int]*models.DriverStats) {
+	player := focusedDriver(drivers)
+
+	var fuelText strings.Builder
+	if player != nil {
+		fuelText.WriteString(formatFuel("[yellow][::b]Player[::-][-]", player, stats[player.SlotID]))
+	}
+	if selected, ok := drivers[d.selectedSlot]; d.hasSelection && ok && (player == nil || selected.SlotID != player.SlotID) {
+		if fuelText.Len() > 0 {
+			fuelText.WriteString("\n")
+		}
+		fuelText.WriteString(formatFuel("[aqua][::b]Selected[::-][-]", selected, stats[selected.SlotID]))
+	}
+	if fuelText.Len() == 0 {
+		fuelText.WriteString("No player car...\nPress N/P to select a car")
+	}
+
+	d.fuelBox.SetText(fuelText.String())
+}
+
+func formatFuel(title string, driver *models.StandingsData, stat *models.DriverStats) string {
+	var fuel models.FuelEstimate
+	number := driver.VehicleNumber
+	if stat != nil {
+		fuel = stat.Fuel
+		number = stat.VehicleNumber
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("%s #%s %s\n", title, number, truncate(driver.DriverName, 28)))
+	text.WriteString(fmt.Sprintf(" Fuel      %7s   Last     %7s\n", formatPercent(driver.FuelFraction), formatPercent(fuel.LastLapUsage)))
+	text.WriteString(fmt.Sprintf(" Avg (5)   %7s   Stint    %7s\n", formatPercent(fuel.RollingAverage), formatPercent(fuel.StintAverage)))
+	text.WriteString(fmt.Sprintf(" Laps on fuel %6s   Laps to go %6s\n", formatLaps(fuel.LapsRemaining), formatLaps(fuel.SessionLapsRemaining)))
+
+	switch {
+	case fuel.RollingAverage <= 0 || fuel.SessionLapsRemaining <= 0:
+		text.WriteString(" [gray]Waiting for clean laps...[-]\n")
+	case fuel.LapsRemaining >= fuel.SessionLapsRemaining:
+		text.WriteString(fmt.Sprintf(" [green]Enough, %.1f laps spare[-]\n", fuel.LapsRemaining-fuel.SessionLapsRemaining))
+	default:
+		text.WriteString(fmt.Sprintf(" [red]Short by %.1f laps (%s more)[-]\n",
+			fuel.SessionLapsRemaining-fuel.LapsRemaining, formatPercent(fuel.FuelToFinish-driver.FuelFraction)))
+	}
+	return text.String()
+}
+
+func formatPercent(fraction float64) string {
+	if fraction <= 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.1f%%", fraction*100)
+}
+
+func formatLaps(laps float64) string {
+	if laps <= 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.1f", laps)
+}
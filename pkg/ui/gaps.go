@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+type classGap struct {
+	gapLaps      int
+	gap          float64
+	intervalLaps int
+	interval     float64
+}
+
+type gapTotal struct {
+	laps    int
+	seconds float64
+}
+
+// classGaps works out the gap to the class leader and to the car ahead in the
+// same class. The game only reports gaps to the overall leader and the car
+// directly ahead, so the intervals are summed along the running order.
+// driverList must be sorted by position.
+func classGaps(driverList []*models.StandingsData) map[int]classGap {
+	gaps := make(map[int]classGap, len(driverList))
+	leaders := make(map[string]gapTotal)
+	ahead := make(map[string]gapTotal)
+
+	var total gapTotal
+	for i, driver := range driverList {
+		if i > 0 {
+			total.laps += driver.LapsBehindNext
+			total.seconds += driver.TimeBehindNext
+		}
+
+		leader, ok := leaders[driver.CarClass]
+		if !ok {
+			leaders[driver.CarClass] = total
+			ahead[driver.CarClass] = total
+			gaps[driver.SlotID] = classGap{}
+			continue
+		}
+
+		previous := ahead[driver.CarClass]
+		gaps[driver.SlotID] = classGap{
+			gapLaps:      total.laps - leader.laps,
+			gap:          total.seconds - leader.seconds,
+			intervalLaps: total.laps - previous.laps,
+			interval:     total.seconds - previous.seconds,
+		}
+		ahead[driver.CarClass] = total
+	}
+	return gaps
+}
+
+func formatGap(laps int, seconds float64) string {
+	if laps > 0 {
+		return fmt.Sprintf("+%d L", laps)
+	}
+	if seconds <= 0 {
+		return "-"
+	}
+	if seconds < 60 {
+		return fmt.Sprintf("+%.3f", seconds)
+	}
+	return "+" + formatTime(seconds)
+}
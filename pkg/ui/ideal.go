@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+func (d *Display) UpdateIdealLaps(idealLaps map[string]*models.IdealLap) {
+	laps := make([]*models.IdealLap, 0, len(idealLaps))
+	for _, lap := range idealLaps {
+		if lap.LapTime() > 0 {
+			laps = append(laps, lap)
+		}
+	}
+	if len(laps) == 0 {
+		d.idealBox.SetText("Waiting for clean laps...")
+		return
+	}
+
+	sort.Slice(laps, func(i, j int) bool {
+		return laps[i].LapTime() < laps[j].LapTime()
+	})
+
+	var idealText strings.Builder
+	for i, lap := range laps {
+		if i > 0 {
+			idealText.WriteString("\n")
+		}
+		idealText.WriteString(fmt.Sprintf("[aqua][::b]%-20s[::-][-] [purple]%s[-]\n", truncate(lap.CarClass, 20), formatTime(lap.LapTime())))
+		idealText.WriteString(formatIdealSector("S1", lap.Sector1))
+		idealText.WriteString(formatIdealSector("S2", lap.Sector2))
+		idealText.WriteString(formatIdealSector("S3", lap.Sector3))
+	}
+	d.idealBox.SetText(idealText.String())
+}
+
+func formatIdealSector(name string, sector models.IdealSector) string {
+	return fmt.Sprintf(" %s %8s #%-4s %s\n", name, formatTime(sector.Time), sector.VehicleNumber, truncate(sector.DriverName, 22))
+}
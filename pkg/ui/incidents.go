@@ -0,0 +1,45 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+var incidentColors = map[string]string{
+	models.IncidentOffTrack: "yellow",
+	models.IncidentSpin:     "orange",
+	models.IncidentContact:  "red",
+}
+
+func (d *Display) UpdateIncidents(incidents []models.Incident) {
+	if len(incidents) == 0 {
+		d.incidentsBox.SetText("No incidents yet...")
+		return
+	}
+
+	_, _, _, height := d.incidentsBox.GetInnerRect()
+	if height <= 0 {
+		height = 10
+	}
+
+	var incidentsText strings.Builder
+	for i := len(incidents) - 1; i >= 0 && i >= len(incidents)-height; i-- {
+		incident := incidents[i]
+		yellow := ""
+		if incident.UnderYellow {
+			yellow = " [yellow]Y[-]"
+		}
+		incidentsText.WriteString(fmt.Sprintf("%s L%-3d #%-3s [%s]%-9s[-] %s%s\n",
+			incident.Time.Format("15:04:05"),
+			incident.Lap,
+			truncate(incident.VehicleNumber, 3),
+			incidentColors[incident.Kind],
+			incident.Kind,
+			truncate(incident.DriverName, 13),
+			yellow,
+		))
+	}
+	d.incidentsBox.SetText(incidentsText.String())
+}
@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+const (
+	flashDuration = 4 * time.Second
+	flashPeriod   = 500 * time.Millisecond
+)
+
+const (
+	colorClassBest    = "purple"
+	colorPersonalBest = "green"
+	colorSlower       = "yellow"
+)
+
+type flashKey struct {
+	slotID int
+	column string
+}
+
+type flashState struct {
+	value float64
+	setAt time.Time
+}
+
+// timingBests holds the class bests that lap and sector times are compared
+// against when coloring the tables.
+type timingBests struct {
+	lap     map[string]float64
+	sectors map[string][3]float64
+}
+
+func newTimingBests(snapshot *models.Snapshot) timingBests {
+	bests := timingBests{
+		lap:     make(map[string]float64),
+		sectors: make(map[string][3]float64),
+	}
+	if snapshot == nil {
+		return bests
+	}
+
+	for _, stat := range snapshot.Stats {
+		lapTime := personalBestLap(stat)
+		if best, ok := bests.lap[stat.CarClass]; lapTime > 0 && (!ok || lapTime < best) {
+			bests.lap[stat.CarClass] = lapTime
+		}
+	}
+	for class, ideal := range snapshot.IdealLaps {
+		bests.sectors[class] = [3]float64{ideal.Sector1.Time, ideal.Sector2.Time, ideal.Sector3.Time}
+	}
+	return bests
+}
+
+func personalBestLap(stat *models.DriverStats) float64 {
+	if stat == nil {
+		return 0
+	}
+	if stat.BestLapTime > 0 {
+		return stat.BestLapTime
+	}
+	return stat.BestLapTimeCalculated
+}
+
+func personalBestSector(stat *models.DriverStats, sector int) float64 {
+	if stat == nil {
+		return 0
+	}
+	return [3]float64{stat.IdealSector1, stat.IdealSector2, stat.IdealSector3}[sector]
+}
+
+func timeColor(value float64, classBest float64, personalBest float64) string {
+	switch {
+	case value <= 0:
+		return ""
+	case classBest > 0 && value <= classBest:
+		return colorClassBest
+	case personalBest > 0 && value <= personalBest:
+		return colorPersonalBest
+	case personalBest > 0:
+		return colorSlower
+	}
+	return ""
+}
+
+func (b timingBests) lapColor(class string, value float64, stat *models.DriverStats) string {
+	return timeColor(value, b.lap[class], personalBestLap(stat))
+}
+
+func (b timingBests) sectorColor(class string, sector int, value float64, stat *models.DriverStats) string {
+	return timeColor(value, b.sectors[class][sector], personalBestSector(stat, sector))
+}
+
+// liveSectors returns the sectors of the lap in progress once its first
+// sector is done, and the sectors of the last lap before that.
+func liveSectors(driver *models.StandingsData) ([3]float64, bool) {
+	var sectors [3]float64
+	if driver.CurrentSectorTime1 > 0 {
+		sectors[0] = driver.CurrentSectorTime1
+		if driver.CurrentSectorTime2 > driver.CurrentSectorTime1 {
+			sectors[1] = driver.CurrentSectorTime2 - driver.CurrentSectorTime1
+		}
+		return sectors, true
+	}
+	if driver.LastLapTime > 0 && driver.LastSectorTime1 > 0 && driver.LastSectorTime2 > driver.LastSectorTime1 {
+		sectors[0] = driver.LastSectorTime1
+		sectors[1] = driver.LastSectorTime2 - driver.LastSectorTime1
+		sectors[2] = driver.LastLapTime - driver.LastSectorTime2
+	}
+	return sectors, false
+}
+
+func formatSector(seconds float64) string {
+	if seconds <= 0 {
+		return ""
+	}
+	return formatTime(seconds)
+}
+
+// flashing reports whether a value shown in a column has changed within the
+// last few seconds, alternating so that the cell blinks. The time comes from
+// the snapshot, so replays flash at their own pace.
+func (d *Display) flashing(slotID int, column string, value float64) bool {
+	var now time.Time
+	if d.current != nil {
+		now = d.current.Time
+	}
+
+	key := flashKey{slotID: slotID, column: column}
+	state, seen := d.flashes[key]
+	if !seen || state.value != value {
+		state = flashState{value: value}
+		if seen && value > 0 {
+			state.setAt = now
+		}
+		d.flashes[key] = state
+	}
+
+	if state.setAt.IsZero() {
+		return false
+	}
+	elapsed := now.Sub(state.setAt)
+	return elapsed < flashDuration && (elapsed/flashPeriod)%2 == 0
+}
+
+// colorTime wraps already padded text in color tags. Flashing cells swap to
+// a colored background; only the colors are reset afterwards so the reverse
+// highlight of a selected row is kept.
+func colorTime(text string, color string, flash bool) string {
+	if color == "" {
+		return text
+	}
+	if flash {
+		return "[black:" + color + "]" + text + "[-:-]"
+	}
+	return "[" + color + "]" + text + "[-]"
+}
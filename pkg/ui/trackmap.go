@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/mslomnicki/LMURacingTelemetry/pkg/models"
+)
+
+var classColors = []string{"red", "blue", "green", "orange", "fuchsia", "aqua"}
+
+// Bits of the Braille dots in a 2x4 character cell, indexed by [x][y].
+var brailleDots = [2][4]rune{
+	{0x01, 0x02, 0x04, 0x40},
+	{0x08, 0x10, 0x20, 0x80},
+}
+
+type brailleCanvas struct {
+	width  int
+	height int
+	dots   []rune
+	marks  map[int]string
+}
+
+func newBrailleCanvas(width int, height int) *brailleCanvas {
+	return &brailleCanvas{
+		width:  width,
+		height: height,
+		dots:   make([]rune, width*height),
+		marks:  make(map[int]string),
+	}
+}
+
+func (c *brailleCanvas) set(x int, y int) {
+	if x < 0 || y < 0 || x >= c.width*2 || y >= c.height*4 {
+		return
+	}
+	c.dots[(y/4)*c.width+x/2] |= brailleDots[x%2][y%4]
+}
+
+func (c *brailleCanvas) line(x0 int, y0 int, x1 int, y1 int) {
+	steps := max(abs(x1-x0), abs(y1-y0))
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		c.set(x0+int(math.Round(t*float64(x1-x0))), y0+int(math.Round(t*float64(y1-y0))))
+	}
+}
+
+func (c *brailleCanvas) mark(x int, y int, text string) {
+	if x < 0 || y < 0 || x >= c.width*2 || y >= c.height*4 {
+		return
+	}
+	c.marks[(y/4)*c.width+x/2] = text
+}
+
+func (c *brailleCanvas) String() string {
+	var text strings.Builder
+	for row := 0; row < c.height; row++ {
+		text.WriteString("[gray]")
+		for col := 0; col < c.width; col++ {
+			index := row*c.width + col
+			if mark, ok := c.marks[index]; ok {
+				text.WriteString(mark + "[gray]")
+				continue
+			}
+			text.WriteRune(0x2800 + c.dots[index])
+		}
+		text.WriteString("[-]\n")
+	}
+	return text.String()
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+type mapProjection struct {
+	minX, minZ float64
+	scale      float64
+	offsetX    float64
+	offsetY    float64
+}
+
+func newMapProjection(points []models.TrackPoint, width int, height int) mapProjection {
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	minZ, maxZ := math.Inf(1), math.Inf(-1)
+	for _, point := range points {
+		minX, maxX = math.Min(minX, point.X), math.Max(maxX, point.X)
+		minZ, maxZ = math.Min(minZ, point.Z), math.Max(maxZ, point.Z)
+	}
+
+	dotsX, dotsY := float64(width*2-1), float64(height*4-1)
+	scale := math.Min(dotsX/math.Max(maxX-minX, 1), dotsY/math.Max(maxZ-minZ, 1))
+	return mapProjection{
+		minX:    minX,
+		minZ:    minZ,
+		scale:   scale,
+		offsetX: (dotsX - (maxX-minX)*scale) / 2,
+		offsetY: (dotsY - (maxZ-minZ)*scale) / 2,
+	}
+}
+
+func (p mapProjection) project(x float64, z float64) (int, int) {
+	return int(math.Round(p.offsetX + (x-p.minX)*p.scale)), int(math.Round(p.offsetY + (z-p.minZ)*p.scale))
+}
+
+func (d *Display) UpdateTrackMap(trackMap *models.TrackMap, drivers map[int]*models.StandingsData) {
+	if trackMap == nil || len(trackMap.Points) < 2 {
+		d.mapBox.SetText("Mapping the track from car positions...")
+		return
+	}
+	_, _, width, height := d.mapBox.GetInnerRect()
+	// The last line holds the class legend.
+	height--
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	canvas := newBrailleCanvas(width, height)
+	projection := newMapProjection(trackMap.Points, width, height)
+	last := trackMap.Points[len(trackMap.Points)-1]
+	prevX, prevY := projection.project(last.X, last.Z)
+	for _, point := range trackMap.Points {
+		x, y := projection.project(point.X, point.Z)
+		canvas.line(prevX, prevY, x, y)
+		prevX, prevY = x, y
+	}
+
+	classes := make([]string, 0)
+	for _, driver := range drivers {
+		if !slices.Contains(classes, driver.CarClass) {
+			classes = append(classes, driver.CarClass)
+		}
+	}
+	sort.Strings(classes)
+	colors := make(map[string]string, len(classes))
+	for i, class := range classes {
+		colors[class] = classColors[i%len(classColors)]
+	}
+
+	driverList := make([]*models.StandingsData, 0, len(drivers))
+	for _, driver := range drivers {
+		driverList = append(driverList, driver)
+	}
+	// Leaders are drawn last so they stay visible when cars share a cell.
+	sort.Slice(driverList, func(i, j int) bool {
+		return driverList[i].Position > driverList[j].Position
+	})
+	for _, driver := range driverList {
+		if driver.InGarageStall {
+			continue
+		}
+		color := colors[driver.CarClass]
+		if driver.Player || driver.HasFocus {
+			color = "yellow"
+		}
+		x, y := projection.project(driver.CarPosition.X, driver.CarPosition.Z)
+		canvas.mark(x, y, "["+color+"]●")
+	}
+
+	var legend strings.Builder
+	for _, class := range classes {
+		legend.WriteString("[" + colors[class] + "]●[-] " + class + " ")
+	}
+	legend.WriteString("[yellow]●[-] You")
+	d.mapBox.SetText(canvas.String() + legend.String())
+}